@@ -0,0 +1,115 @@
+package urlquery
+
+import (
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+// DefaultMaxBodySize is the size cap of form body, if it is not specified by WithFormBody
+const DefaultMaxBodySize int64 = 10 << 20
+
+// A BindPrecedence decides which source wins when a key appears in both query string and form body
+type BindPrecedence int
+
+const (
+	// BindQueryFirst uses values of query string, it is default
+	BindQueryFirst BindPrecedence = iota
+	// BindBodyFirst uses values of form body
+	BindBodyFirst
+)
+
+// BindRequest decode query string of request to go structure.
+// With WithFormBody option, application/x-www-form-urlencoded body of POST, PUT and PATCH request is also decoded,
+// and merged with query string according to WithBindPrecedence option.
+// Decoding failure is returned as path-aware *DecodeError, which can be mapped to 400 Bad Request.
+func BindRequest(r *http.Request, v interface{}, opts ...Option) error {
+	p := NewParser(opts...)
+	p.resetQueryEncoder()
+	if err := p.limits.checkTotalBytes(int64(len(r.URL.RawQuery))); err != nil {
+		return err
+	}
+
+	values, err := p.splitValues([]byte(r.URL.RawQuery))
+	if err != nil {
+		return err
+	}
+
+	if p.opts.formBody {
+		body, err := p.readFormBody(r)
+		if err != nil {
+			return err
+		}
+		mergeValues(values, body, p.opts.bindPrecedence == BindBodyFirst)
+
+		//body is capped by WithFormBody, so merged values may exceed MaxTotalBytes checked for query string
+		if total := p.maxBodySize() + int64(len(r.URL.RawQuery)); p.limits.MaxTotalBytes > 0 && p.limits.MaxTotalBytes < total {
+			p.limits.MaxTotalBytes = total
+		}
+	}
+
+	return p.UnmarshalValues(values, v)
+}
+
+// read values from application/x-www-form-urlencoded body, body of other content type is ignored
+func (p *parser) readFormBody(r *http.Request) (url.Values, error) {
+	//form body has been parsed, eg. by middleware calling ParseForm
+	if r.PostForm != nil {
+		return r.PostForm, nil
+	}
+
+	if r.Body == nil || (r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch) {
+		return nil, nil
+	}
+
+	ct, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || ct != "application/x-www-form-urlencoded" {
+		return nil, nil
+	}
+
+	limit := p.maxBodySize()
+
+	//read one more byte to detect body exceeding the cap
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrBodyTooLarge{limit: limit}
+	}
+
+	return p.splitValues(data)
+}
+
+// get size cap of form body
+func (p *parser) maxBodySize() int64 {
+	if p.opts.maxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+	return p.opts.maxBodySize
+}
+
+// split string data into url.Values, by the query encoder of parser
+func (p *parser) splitValues(data []byte) (url.Values, error) {
+	values := url.Values{}
+	err := p.split(data, func(key, value string) error {
+		values.Add(key, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// merge src into dst, the key existing in both of them is overwritten only if override is true
+func mergeValues(dst, src url.Values, override bool) {
+	for k, v := range src {
+		if _, ok := dst[k]; ok && !override {
+			continue
+		}
+		dst[k] = v
+	}
+}
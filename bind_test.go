@@ -0,0 +1,163 @@
+package urlquery
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testBindData struct {
+	Id    int      `query:"id"`
+	Name  string   `query:"name"`
+	Tags  []string `query:"tags"`
+	Child struct {
+		Age int `query:"age"`
+	} `query:"child"`
+}
+
+func newFormRequest(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	return r
+}
+
+func TestBindRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?id=1&name=a%20b&tags%5B%5D=x&tags%5B%5D=y&child%5Bage%5D=3", nil)
+	v := testBindData{}
+	err := BindRequest(r, &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v.Id != 1 || v.Name != "a b" || !reflect.DeepEqual(v.Tags, []string{"x", "y"}) || v.Child.Age != 3 {
+		t.Error("failed to bind query string", v)
+	}
+}
+
+func TestBindRequest_FormBody(t *testing.T) {
+	r := newFormRequest(http.MethodPost, "/?id=1&name=q", "name=b&child%5Bage%5D=5")
+	v := testBindData{}
+	err := BindRequest(r, &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if v.Name != "q" || v.Child.Age != 0 {
+		t.Error("body should be ignored without WithFormBody", v)
+	}
+
+	r = newFormRequest(http.MethodPost, "/?id=1&name=q", "name=b&child%5Bage%5D=5")
+	v = testBindData{}
+	err = BindRequest(r, &v, WithFormBody(0))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if v.Id != 1 || v.Name != "q" || v.Child.Age != 5 {
+		t.Error("failed to bind form body with query first", v)
+	}
+
+	r = newFormRequest(http.MethodPut, "/?id=1&name=q", "name=b&child%5Bage%5D=5")
+	v = testBindData{}
+	err = BindRequest(r, &v, WithFormBody(0), WithBindPrecedence(BindBodyFirst))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if v.Id != 1 || v.Name != "b" || v.Child.Age != 5 {
+		t.Error("failed to bind form body with body first", v)
+	}
+}
+
+func TestBindRequest_IgnoredBody(t *testing.T) {
+	r := newFormRequest(http.MethodGet, "/?id=1", "name=b")
+	v := testBindData{}
+	err := BindRequest(r, &v, WithFormBody(0))
+	if err != nil || v.Name != "" {
+		t.Error("body of GET request should be ignored", v, err)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/?id=1", strings.NewReader(`{"name":"b"}`))
+	r.Header.Set("Content-Type", "application/json")
+	v = testBindData{}
+	err = BindRequest(r, &v, WithFormBody(0))
+	if err != nil || v.Name != "" {
+		t.Error("body of other content type should be ignored", v, err)
+	}
+}
+
+func TestBindRequest_ParsedForm(t *testing.T) {
+	r := newFormRequest(http.MethodPost, "/", "name=b")
+	if err := r.ParseForm(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	v := testBindData{}
+	err := BindRequest(r, &v, WithFormBody(0))
+	if err != nil || v.Name != "b" {
+		t.Error("failed to bind parsed form", v, err)
+	}
+}
+
+func TestBindRequest_BodyTooLarge(t *testing.T) {
+	r := newFormRequest(http.MethodPost, "/", "name=0123456789")
+	v := testBindData{}
+	err := BindRequest(r, &v, WithFormBody(10))
+	if e := (ErrBodyTooLarge{}); !errors.As(err, &e) || e.Limit() != 10 {
+		t.Error("unmatched error", err)
+	}
+
+	r = newFormRequest(http.MethodPost, "/", "name=012345")
+	err = BindRequest(r, &v, WithFormBody(11))
+	if err != nil || v.Name != "012345" {
+		t.Error("body within limit should be bound", v, err)
+	}
+
+	//body within its cap is bound even if it exceeds MaxTotalBytes, which still limits query string
+	r = newFormRequest(http.MethodPost, "/?id=1", "name=0123456789abcdef")
+	err = BindRequest(r, &v, WithFormBody(100), WithLimits(Limits{MaxTotalBytes: 10}))
+	if err != nil || v.Name != "0123456789abcdef" || v.Id != 1 {
+		t.Error("body within limit should be bound", v, err)
+	}
+
+	r = newFormRequest(http.MethodPost, "/?name=0123456789", "id=1")
+	err = BindRequest(r, &v, WithFormBody(100), WithLimits(Limits{MaxTotalBytes: 10}))
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxTotalBytes" {
+		t.Error("unmatched error", err)
+	}
+}
+
+func TestBindRequest_Error(t *testing.T) {
+	r := newFormRequest(http.MethodPost, "/?id=x", "child%5Bage%5D=y")
+	v := testBindData{}
+	err := BindRequest(r, &v, WithFormBody(0), WithCollectErrors())
+
+	var errs DecodeErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Error("unmatched error", err)
+		return
+	}
+	keys := map[string]string{}
+	for _, e := range errs {
+		var de *DecodeError
+		if errors.As(e, &de) {
+			keys[de.Key] = de.Field
+		}
+	}
+	if !reflect.DeepEqual(keys, map[string]string{"id": "Id", "child[age]": "Child.Age"}) {
+		t.Error("unmatched error path", keys)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.URL.RawQuery = "name=%zz"
+	err = BindRequest(r, &v)
+	if e := (url.EscapeError("")); !errors.As(err, &e) {
+		t.Error("unmatched error", err)
+	}
+}
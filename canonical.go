@@ -0,0 +1,66 @@
+package urlquery
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// A pair of escaped key and value
+type queryPair struct {
+	key   string
+	value string
+}
+
+// sort pairs byte-wise by key, and then by value
+func sortQueryPairs(pairs []queryPair) {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].key != pairs[j].key {
+			return pairs[i].key < pairs[j].key
+		}
+		return pairs[i].value < pairs[j].value
+	})
+}
+
+// join pairs into query string
+func joinQueryPairs(pairs []queryPair) []byte {
+	buf := new(bytes.Buffer)
+	for i, pair := range pairs {
+		if i > 0 {
+			buf.WriteString(SymbolAnd)
+		}
+		buf.WriteString(pair.key + SymbolEqual + pair.value)
+	}
+	return buf.Bytes()
+}
+
+// Canonicalize convert URL Query string to canonical form, which is used for request signing.
+// Keys and values are re-encoded by RFC3986QueryEncoder, eg. + and %2b are turned to %20 and %2B,
+// pairs are sorted byte-wise by key and then by value, and empty values are kept, eg. `a` is turned to `a=`.
+func Canonicalize(query []byte) ([]byte, error) {
+	qe := RFC3986QueryEncoder{}
+	var pairs []queryPair
+	for _, s := range strings.Split(string(query), SymbolAnd) {
+		if s == "" {
+			continue
+		}
+
+		ns := strings.SplitN(s, SymbolEqual, 2)
+		if len(ns) == 1 {
+			ns = append(ns, "")
+		}
+
+		key, err := qe.UnEscape(ns[0])
+		if err != nil {
+			return nil, err
+		}
+		value, err := qe.UnEscape(ns[1])
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, queryPair{key: qe.Escape(key), value: qe.Escape(value)})
+	}
+
+	sortQueryPairs(pairs)
+	return joinQueryPairs(pairs), nil
+}
@@ -0,0 +1,126 @@
+package urlquery
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRFC3986QueryEncoder(t *testing.T) {
+	cases := []struct {
+		raw     string
+		escaped string
+	}{
+		{"", ""},
+		{"AZaz09-._~", "AZaz09-._~"},
+		{"a b", "a%20b"},
+		{"a+b", "a%2Bb"},
+		{"*", "%2A"},
+		{"!'()", "%21%27%28%29"},
+		{"/?#[]@", "%2F%3F%23%5B%5D%40"},
+		{"=&%", "%3D%26%25"},
+		{"测试", "%E6%B5%8B%E8%AF%95"},
+		{"\x00\xff", "%00%FF"},
+	}
+
+	qe := RFC3986QueryEncoder{}
+	for _, c := range cases {
+		if s := qe.Escape(c.raw); s != c.escaped {
+			t.Error("unmatched result of Escape", c.raw, s)
+		}
+		if s, err := qe.UnEscape(c.escaped); err != nil || s != c.raw {
+			t.Error("unmatched result of UnEscape", c.escaped, s, err)
+		}
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	cases := []struct {
+		query     string
+		canonical string
+	}{
+		{"", ""},
+		{"b=2&a=1", "a=1&b=2"},
+		{"a=2&a=1&a=10", "a=1&a=10&a=2"},
+		{"a+b=c+d", "a%20b=c%20d"},
+		{"a%20b=c%2bd", "a%20b=c%2Bd"},
+		{"key=%7e%2D", "key=~-"},
+		{"a=&b", "a=&b="},
+		{"&&a=1&", "a=1"},
+		{"a=b=c", "a=b%3Dc"},
+		{"filter%5Bname%5D=x&filter[id]=1", "filter%5Bid%5D=1&filter%5Bname%5D=x"},
+		{"a-b=1&a=2&A=3", "A=3&a=2&a-b=1"},
+		{"%E6%B5%8B=%e8%af%95", "%E6%B5%8B=%E8%AF%95"},
+	}
+
+	for _, c := range cases {
+		bs, err := Canonicalize([]byte(c.query))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(bs) != c.canonical {
+			t.Error("unmatched result of Canonicalize", c.query, string(bs))
+		}
+	}
+
+	_, err := Canonicalize([]byte("a=%zz"))
+	if err == nil {
+		t.Error("error is expected")
+	}
+	_, err = Canonicalize([]byte("%zz=a"))
+	if err == nil {
+		t.Error("error is expected")
+	}
+}
+
+func TestEncoder_Marshal_Canonical(t *testing.T) {
+	data := map[string]interface{}{
+		"b":       []string{"y", "x"},
+		"a b":     "c+d",
+		"empty":   "",
+		"a-b":     1,
+		"filter":  map[string]string{"name": "~x*"},
+		"unicode": "测试",
+	}
+	expected := "a%20b=c%2Bd&a-b=1&b%5B%5D=x&b%5B%5D=y&empty=&filter%5Bname%5D=~x%2A&unicode=%E6%B5%8B%E8%AF%95"
+
+	encoder := NewEncoder(WithCanonical(), WithQueryEncoder(&errorQueryEncoder{}))
+	bs, err := encoder.Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(bs) != expected {
+		t.Error("failed to Marshal in canonical form", string(bs))
+	}
+
+	//canonical form of canonical form is itself
+	bs, err = Canonicalize(bs)
+	if err != nil || string(bs) != expected {
+		t.Error("unmatched result of Canonicalize", string(bs), err)
+	}
+
+	//zero-valued struct fields are kept only if empty value is needed
+	st := struct{ B, A, E string }{B: "x y", A: "~"}
+	bs, err = NewEncoder(WithCanonical()).Marshal(st)
+	if err != nil || string(bs) != "A=~&B=x%20y" {
+		t.Error("failed to Marshal struct in canonical form", string(bs), err)
+	}
+	bs, err = NewEncoder(WithCanonical(), WithNeedEmptyValue(true)).Marshal(st)
+	if err != nil || string(bs) != "A=~&B=x%20y&E=" {
+		t.Error("failed to Marshal struct with empty value in canonical form", string(bs), err)
+	}
+
+	buf := new(bytes.Buffer)
+	e := NewStreamEncoder(buf, WithCanonical())
+	_ = e.Encode(map[string]int{"b": 2, "a": 1})
+	_ = e.Encode(map[string]int{"d": 4, "c": 3})
+	if buf.String() != "a=1&b=2&c=3&d=4" {
+		t.Error("failed to encode stream in canonical form", buf.String())
+	}
+
+	err = NewStreamEncoder(errorWriter{}, WithCanonical()).Encode(map[string]int{"b": 2, "a": 1})
+	if err == nil || err.Error() != "write" {
+		t.Error("unmatched error", err)
+	}
+}
@@ -0,0 +1,66 @@
+package urlquery
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Converters is a registry of encode and decode functions keyed by reflect.Type.
+// Unlike RegisterEncodeFunc and RegisterDecodeFunc keyed by reflect.Kind, it just affects the specified type.
+// It can be shared by encoder and parser with WithConverters option, so that encoding and decoding stay symmetric.
+// It is thread safety
+type Converters struct {
+	mutex    sync.RWMutex
+	encoders map[reflect.Type]valueEncode
+	decoders map[reflect.Type]valueDecode
+}
+
+// NewConverters make a new empty Converters object
+func NewConverters() *Converters {
+	return &Converters{
+		encoders: make(map[reflect.Type]valueEncode),
+		decoders: make(map[reflect.Type]valueDecode),
+	}
+}
+
+// RegisterTypeEncoder register self-defined encode function for specified type.
+// Value of the type is encoded as a single value, pointer to the type is dereferenced before encoding.
+func (c *Converters) RegisterTypeEncoder(typ reflect.Type, encode valueEncode) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.encoders[typ] = encode
+}
+
+// RegisterTypeDecoder register self-defined decode function for specified type.
+// Value of the type is decoded from a single value, pointer to the type is initialized before decoding.
+func (c *Converters) RegisterTypeDecoder(typ reflect.Type, decode valueDecode) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.decoders[typ] = decode
+}
+
+// RegisterType register a pair of self-defined encode and decode function for specified type
+func (c *Converters) RegisterType(typ reflect.Type, encode valueEncode, decode valueDecode) {
+	c.RegisterTypeEncoder(typ, encode)
+	c.RegisterTypeDecoder(typ, decode)
+}
+
+// get registered encode function for specified type
+func (c *Converters) getEncodeFunc(typ reflect.Type) valueEncode {
+	if c == nil {
+		return nil
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.encoders[typ]
+}
+
+// get registered decode function for specified type
+func (c *Converters) getDecodeFunc(typ reflect.Type) valueDecode {
+	if c == nil {
+		return nil
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.decoders[typ]
+}
@@ -0,0 +1,138 @@
+package urlquery
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testCurrency string
+
+type testRange struct {
+	Min, Max int
+}
+
+type testStatus int
+
+type testConverterData struct {
+	Name     string         `query:"name"`
+	Currency testCurrency   `query:"currency"`
+	Range    testRange      `query:"range"`
+	RangePtr *testRange     `query:"rangePtr"`
+	Status   testStatus     `query:"status"`
+	Pairs    []testCurrency `query:"pairs"`
+}
+
+func getTestConverters() *Converters {
+	c := NewConverters()
+	c.RegisterType(reflect.TypeOf(testCurrency("")), func(rv reflect.Value) string {
+		return strings.ToUpper(rv.String())
+	}, func(s string) (reflect.Value, error) {
+		return reflect.ValueOf(strings.ToLower(s)), nil
+	})
+	c.RegisterType(reflect.TypeOf(testRange{}), func(rv reflect.Value) string {
+		r := rv.Interface().(testRange)
+		return intEncode(reflect.ValueOf(r.Min)) + "-" + intEncode(reflect.ValueOf(r.Max))
+	}, func(s string) (reflect.Value, error) {
+		arr := strings.SplitN(s, "-", 2)
+		if len(arr) != 2 {
+			return reflect.Value{}, errors.New("invalid range")
+		}
+		min, err := baseIntDecode(arr[0], 0)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		max, err := baseIntDecode(arr[1], 0)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(testRange{Min: int(min.Int()), Max: int(max.Int())}), nil
+	})
+	return c
+}
+
+func TestConverters_Marshal(t *testing.T) {
+	c := getTestConverters()
+	data := testConverterData{
+		Name:     "usd",
+		Currency: "usd",
+		Range:    testRange{Min: 1, Max: 5},
+		RangePtr: &testRange{Min: 2, Max: 3},
+		Status:   2,
+		Pairs:    []testCurrency{"eur", "cny"},
+	}
+	encoder := NewEncoder(WithConverters(c), WithQueryEncoder(&errorQueryEncoder{}))
+	bytes, err := encoder.Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if string(bytes) != "name=usd&currency=USD&range=1-5&rangePtr=2-3&status=2&pairs[]=EUR&pairs[]=CNY" {
+		t.Error("failed to Marshal with converters", string(bytes))
+		return
+	}
+
+	v := testConverterData{}
+	parser := NewParser(WithConverters(c), WithQueryEncoder(&errorQueryEncoder{errorAt: 100}))
+	err = parser.Unmarshal(bytes, &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !reflect.DeepEqual(v, data) {
+		t.Error("failed to Unmarshal with converters", v)
+	}
+}
+
+func TestConverters_DecodeError(t *testing.T) {
+	parser := NewParser(WithConverters(getTestConverters()))
+	err := parser.Unmarshal([]byte("range=1"), &testConverterData{})
+	if err == nil || errors.Unwrap(err).Error() != "invalid range" {
+		t.Error("unmatched error", err)
+	}
+}
+
+func TestConverters_Register(t *testing.T) {
+	encoder := NewEncoder()
+	encoder.RegisterTypeEncoder(reflect.TypeOf(testStatus(0)), func(rv reflect.Value) string {
+		return "s" + intEncode(rv)
+	})
+	bytes, err := encoder.Marshal(testConverterData{Name: "a", Status: 3})
+	if err != nil || string(bytes) != "name=a&status=s3" {
+		t.Error("failed to RegisterTypeEncoder", err, string(bytes))
+	}
+
+	parser := NewParser()
+	parser.RegisterTypeDecoder(reflect.TypeOf(testStatus(0)), func(s string) (reflect.Value, error) {
+		return baseIntDecode(strings.TrimPrefix(s, "s"), 0)
+	})
+	v := testConverterData{}
+	err = parser.Unmarshal(bytes, &v)
+	if err != nil || v.Name != "a" || v.Status != 3 {
+		t.Error("failed to RegisterTypeDecoder", err, v)
+	}
+}
+
+func TestConverters_Concurrent(t *testing.T) {
+	c := NewConverters()
+	typ := reflect.TypeOf(testCurrency(""))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.RegisterTypeEncoder(typ, stringEncode)
+			_ = c.getEncodeFunc(typ)
+		}()
+	}
+	wg.Wait()
+
+	var nilConverters *Converters
+	if nilConverters.getEncodeFunc(typ) != nil || nilConverters.getDecodeFunc(typ) != nil {
+		t.Error("nil converters should return nil")
+	}
+}
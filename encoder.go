@@ -2,8 +2,12 @@ package urlquery
 
 import (
 	"bytes"
+	"io"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -16,7 +20,10 @@ const (
 
 // A encoder from go structure data to URL Query string
 type encoder struct {
-	buffer        *bytes.Buffer
+	writer        io.Writer
+	written       int
+	values        url.Values
+	pairs         []queryPair
 	err           error
 	opts          options
 	mutex         sync.Mutex
@@ -32,50 +39,78 @@ func NewEncoder(opts ...Option) *encoder {
 		option(&b.opts)
 	}
 	b.encodeFuncMap = make(map[reflect.Kind]valueEncode)
+	if b.opts.converters == nil {
+		b.opts.converters = NewConverters()
+	}
 	return b
 }
 
-// reset query encoder
+// reset query encoder, canonical form always uses RFC3986QueryEncoder
 func (b *encoder) resetQueryEncoder() {
-	if b.opts.queryEncoder != nil {
+	if b.opts.canonical {
+		b.queryEncoder = RFC3986QueryEncoder{}
+	} else if b.opts.queryEncoder != nil {
 		b.queryEncoder = b.opts.queryEncoder
 	} else {
 		b.queryEncoder = getQueryEncoder()
 	}
 }
 
-// generate next parent node key
+// generate next parent node key according to key style
 func (b *encoder) genNextParentNode(parentNode, key string) string {
+	if b.opts.keyStyle == KeyStyleDots {
+		return genNextDotParentNode(parentNode, key)
+	}
 	return genNextParentNode(parentNode, key)
 }
 
 // detect type of value via reflect, handle correctly
-func (b *encoder) buildQuery(rv reflect.Value, parentNode string, parentKind reflect.Kind) {
+func (b *encoder) buildQuery(rv reflect.Value, parentNode string, parentKind reflect.Kind, t *tag) {
 	if b.err != nil {
 		return
 	}
 
+	//value of type with registered or built-in converter is encoded as a single value
+	if isConvertibleKind(rv) && b.getTypeEncodeFunc(rv.Type(), t) != nil {
+		b.appendKeyValue(parentNode, rv, parentKind, t)
+		return
+	}
+
+	//value implementing Marshaler owns its entire query representation
+	if m, ok := getMarshaler(rv); ok {
+		b.appendMarshaler(parentNode, m)
+		return
+	}
+
+	//value implementing encoding.TextMarshaler is encoded as a single value
+	if _, ok := getTextMarshaler(rv); ok {
+		b.appendKeyValue(parentNode, rv, parentKind, t)
+		return
+	}
+
 	switch rv.Kind() {
 	case reflect.Map:
-		b.buildQueryForMap(rv, parentNode)
+		b.buildQueryForMap(rv, parentNode, t)
 	case reflect.Slice, reflect.Array:
-		for i := 0; i < rv.Len(); i++ {
-			b.buildQuery(rv.Index(i), b.genNextParentNode(parentNode, strconv.Itoa(i)), rv.Kind())
-		}
+		b.buildQueryForSlice(rv, parentNode, t)
 	case reflect.Struct:
 		b.buildQueryForStruct(rv, parentNode)
 	case reflect.Ptr, reflect.Interface:
 		if !rv.IsNil() {
-			b.buildQuery(rv.Elem(), parentNode, parentKind)
+			b.buildQuery(rv.Elem(), parentNode, parentKind, t)
 		}
 	default:
-		b.appendKeyValue(parentNode, rv, parentKind)
+		b.appendKeyValue(parentNode, rv, parentKind, t)
 	}
 }
 
 // build query string for map value
-func (b *encoder) buildQueryForMap(rv reflect.Value, parentNode string) {
-	for _, key := range rv.MapKeys() {
+// keys are sorted with WithSortedMapKeys option, otherwise in random order of map
+func (b *encoder) buildQueryForMap(rv reflect.Value, parentNode string, t *tag) {
+	keys := rv.MapKeys()
+	checkKeys := make([]reflect.Value, len(keys))
+	keyStrs := make([]string, len(keys))
+	for i, key := range keys {
 		//If type of key is interface or ptr, check the pointed element of key
 		checkKey := key
 		if key.Kind() == reflect.Interface || key.Kind() == reflect.Ptr {
@@ -89,72 +124,216 @@ func (b *encoder) buildQueryForMap(rv reflect.Value, parentNode string) {
 		}
 
 		//encode key structure to string
-		keyStr, err := b.encode(checkKey)
+		keyStr, err := b.encode(checkKey, nil)
 		if err != nil {
 			b.err = err
 			return
 		}
+		checkKeys[i] = checkKey
+		keyStrs[i] = keyStr
+	}
+
+	indices := make([]int, len(keys))
+	for i := range indices {
+		indices[i] = i
+	}
+	if b.opts.sortedMapKeys {
+		sort.Slice(indices, func(i, j int) bool {
+			x, y := indices[i], indices[j]
+			return lessMapKey(checkKeys[x], checkKeys[y], keyStrs[x], keyStrs[y])
+		})
+	}
+
+	for _, i := range indices {
+		b.buildQuery(rv.MapIndex(keys[i]), b.genNextParentNode(parentNode, keyStrs[i]), rv.Kind(), t)
+	}
+}
+
+// build query string for slice or array value according to array format
+func (b *encoder) buildQueryForSlice(rv reflect.Value, parentNode string, t *tag) {
+	//elements which are all single values can be joined with separator, except top-level
+	sep := t.getArrayFormat(b.opts.arrayFormat).separator()
+	if sep != "" && parentNode != "" && b.isSingleValues(rv, t) {
+		values := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			elem := indirect(rv.Index(i))
+			if !elem.IsValid() {
+				continue
+			}
+
+			s, err := b.encode(elem, t)
+			if err != nil {
+				b.err = err
+				return
+			}
+			values = append(values, s)
+		}
+
+		if len(values) > 0 {
+			b.writeKeyValue(parentNode, strings.Join(values, sep))
+		}
+		return
+	}
+
+	format := t.getArrayFormat(b.opts.arrayFormat)
+	for i := 0; i < rv.Len(); i++ {
+		key := b.genNextParentNode(parentNode, strconv.Itoa(i))
+
+		//key of single value is repacked according to array format, except top-level
+		//eg. students[0] -> students[] | students[0] | students
+		elem := indirect(rv.Index(i))
+		if parentNode != "" && elem.IsValid() && b.isSingleValue(elem, t) {
+			switch format {
+			case ArrayFormatIndices:
+			case ArrayFormatRepeat:
+				key = parentNode
+			default:
+				key = parentNode + "[]"
+			}
+		}
+
+		b.buildQuery(rv.Index(i), key, rv.Kind(), t)
+	}
+}
+
+// check if all the elements of slice or array are encoded as single values
+func (b *encoder) isSingleValues(rv reflect.Value, t *tag) bool {
+	for i := 0; i < rv.Len(); i++ {
+		elem := indirect(rv.Index(i))
+		if elem.IsValid() && !b.isSingleValue(elem, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// check if value is encoded as a single value
+func (b *encoder) isSingleValue(rv reflect.Value, t *tag) bool {
+	if b.getTypeEncodeFunc(rv.Type(), t) != nil {
+		return true
+	}
+	if _, ok := getMarshaler(rv); ok {
+		return false
+	}
+	if _, ok := getTextMarshaler(rv); ok {
+		return true
+	}
 
-		b.buildQuery(rv.MapIndex(key), b.genNextParentNode(parentNode, keyStr), rv.Kind())
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return false
+	default:
+		return true
 	}
 }
 
 // build query string for struct value
 func (b *encoder) buildQueryForStruct(rv reflect.Value, parentNode string) {
-	rt := rv.Type()
-	for i := 0; i < rt.NumField(); i++ {
-		ft := rt.Field(i)
+	for _, f := range getFields(rv.Type()) {
 		//unexported
-		if ft.PkgPath != "" && !ft.Anonymous {
+		if !f.exported && !f.anonymous {
 			continue
 		}
 
+		fv := rv.Field(f.index)
 		//specially handle anonymous fields
-		if ft.Anonymous && rv.Field(i).Kind() == reflect.Struct {
-			b.buildQuery(rv.Field(i), parentNode, rv.Kind())
+		if f.embedded {
+			b.buildQuery(fv, parentNode, rv.Kind(), nil)
 			continue
 		}
 
-		tag := ft.Tag.Get("query")
-		//all ignore
-		if tag == "-" {
+		//field may be omitted by options of tag
+		if (f.omitEmpty && isZeroValue(fv)) || (f.omitZero && isZeroField(fv)) {
 			continue
 		}
 
-		t := newTag(tag)
-		//get the related name
-		name := t.getName()
-		if name == "" {
-			name = ft.Name
-		}
-
-		b.buildQuery(rv.Field(i), b.genNextParentNode(parentNode, name), rv.Kind())
+		b.buildQuery(fv, b.genNextParentNode(parentNode, f.name), rv.Kind(), f.tag)
 	}
 }
 
 // basic structure can be translated directly
-func (b *encoder) appendKeyValue(key string, rv reflect.Value, parentKind reflect.Kind) {
+func (b *encoder) appendKeyValue(key string, rv reflect.Value, parentKind reflect.Kind, t *tag) {
 	//If parent type is struct and empty value will be ignored by default. unless needEmptyValue is true.
-	if parentKind == reflect.Struct && !b.opts.needEmptyValue && isZeroValue(rv) {
+	//keepempty option of tag always keeps the empty value
+	if parentKind == reflect.Struct && !b.opts.needEmptyValue && !t.contains("keepempty") && isZeroValue(rv) {
 		return
 	}
 
-	//If parent type is slice or array, then repack key. eg. students[0] -> students[]
-	if parentKind == reflect.Slice || parentKind == reflect.Array {
-		key = repackArrayQueryKey(key)
+	s, err := b.encode(rv, t)
+	if err != nil {
+		b.err = err
+		return
 	}
 
-	s, err := b.encode(rv)
+	b.writeKeyValue(key, s)
+}
+
+// append all the key-value pairs generated by Marshaler, sorted by key
+func (b *encoder) appendMarshaler(key string, m Marshaler) {
+	values, err := m.MarshalQuery(key)
 	if err != nil {
-		b.err = err
+		b.err = ErrTranslated{err: err}
 		return
 	}
 
-	b.buffer.WriteString(b.queryEncoder.Escape(key) + SymbolEqual + b.queryEncoder.Escape(s) + SymbolAnd)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range values[k] {
+			b.writeKeyValue(k, v)
+		}
+	}
 }
 
-// encode a specified-type value to string
-func (b *encoder) encode(rv reflect.Value) (s string, err error) {
+// write escaped key-value pair into writer, or raw key-value pair into values when marshaling to url.Values
+// pairs are separated by & character. In canonical form, pairs are collected to be sorted
+func (b *encoder) writeKeyValue(key, value string) {
+	if b.values != nil {
+		b.values.Add(key, value)
+		return
+	}
+
+	if b.pairs != nil {
+		b.pairs = append(b.pairs, queryPair{key: b.queryEncoder.Escape(key), value: b.queryEncoder.Escape(value)})
+		return
+	}
+	b.writePair(b.queryEncoder.Escape(key) + SymbolEqual + b.queryEncoder.Escape(value))
+}
+
+// write escaped pair into writer
+func (b *encoder) writePair(pair string) {
+	if b.written > 0 {
+		pair = SymbolAnd + pair
+	}
+	if _, err := io.WriteString(b.writer, pair); err != nil {
+		b.err = err
+		return
+	}
+	b.written++
+}
+
+// encode a specified-type value to string, options of tag may affect the format
+func (b *encoder) encode(rv reflect.Value, t *tag) (s string, err error) {
+	if encodeFunc := b.getTypeEncodeFunc(rv.Type(), t); encodeFunc != nil {
+		s = encodeFunc(rv)
+		return
+	}
+
+	if m, ok := getTextMarshaler(rv); ok {
+		var bs []byte
+		bs, err = m.MarshalText()
+		if err != nil {
+			err = ErrTranslated{err: err}
+			return
+		}
+		s = string(bs)
+		return
+	}
+
 	encodeFunc := b.getEncodeFunc(rv.Kind())
 	if encodeFunc == nil {
 		err = ErrUnhandledType{typ: rv.Type()}
@@ -172,6 +351,20 @@ func (b *encoder) getEncodeFunc(kind reflect.Kind) valueEncode {
 	return getEncodeFunc(kind)
 }
 
+// get encode function for specified type, registered converter takes priority over built-in one
+func (b *encoder) getTypeEncodeFunc(typ reflect.Type, t *tag) valueEncode {
+	if encodeFunc := b.opts.converters.getEncodeFunc(typ); encodeFunc != nil {
+		return encodeFunc
+	}
+	return getTypeEncodeFunc(typ, t)
+}
+
+// RegisterTypeEncoder register self-defined encode function for specified type.
+// It just affects the specified type, instead of all types of the same reflect kind
+func (b *encoder) RegisterTypeEncoder(typ reflect.Type, encode valueEncode) {
+	b.opts.converters.RegisterTypeEncoder(typ, encode)
+}
+
 // register self-defined encode function for any reflect kind
 func (b *encoder) RegisterEncodeFunc(kind reflect.Kind, encode valueEncode) {
 	b.encodeFuncMap[kind] = encode
@@ -184,24 +377,63 @@ func (b *encoder) Marshal(data interface{}) ([]byte, error) {
 	defer b.mutex.Unlock()
 
 	//for duplicate call
-	b.buffer = new(bytes.Buffer)
+	buffer := new(bytes.Buffer)
+	b.written = 0
+	err := b.encodeTo(buffer, data)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// write key-value pairs of go structure into writer, not thread safety
+// & character is written ahead if any pair has been written before
+func (b *encoder) encodeTo(w io.Writer, data interface{}) error {
+	b.writer = w
 	b.err = nil
 	b.resetQueryEncoder()
 
+	if b.opts.canonical {
+		b.pairs = []queryPair{}
+	}
+
 	rv := reflect.ValueOf(data)
-	b.buildQuery(rv, "", reflect.Interface)
-	if b.err != nil {
-		return nil, b.err
+	b.buildQuery(rv, "", reflect.Interface, nil)
+	if b.err == nil && b.pairs != nil {
+		sortQueryPairs(b.pairs)
+		for _, pair := range b.pairs {
+			if b.writePair(pair.key + SymbolEqual + pair.value); b.err != nil {
+				break
+			}
+		}
 	}
 
-	bs := b.buffer.Bytes()
 	//release resource
-	b.buffer = nil
-	//do not forget to remove the last & character
-	if len(bs) == 0 {
-		return bs, nil
+	b.writer = nil
+	b.pairs = nil
+	return b.err
+}
+
+// MarshalValues do encoding go structure to url.Values, keys and values are not escaped
+// it is thread safety
+func (b *encoder) MarshalValues(data interface{}) (url.Values, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	//for duplicate call
+	b.values = url.Values{}
+	b.err = nil
+	b.resetQueryEncoder()
+
+	rv := reflect.ValueOf(data)
+	b.buildQuery(rv, "", reflect.Interface, nil)
+	values := b.values
+	//release resource
+	b.values = nil
+	if b.err != nil {
+		return nil, b.err
 	}
-	return bs[:len(bs)-1], nil
+	return values, nil
 }
 
 // Marshal do encoding go structure to string
@@ -210,3 +442,10 @@ func Marshal(data interface{}) ([]byte, error) {
 	b := NewEncoder()
 	return b.Marshal(data)
 }
+
+// MarshalValues do encoding go structure to url.Values
+// it is thread safety
+func MarshalValues(data interface{}) (url.Values, error) {
+	b := NewEncoder()
+	return b.MarshalValues(data)
+}
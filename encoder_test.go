@@ -3,9 +3,12 @@ package urlquery
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 type builderChild struct {
@@ -182,7 +185,7 @@ func TestEncoder_Marshal_ErrInvalidMapKeyType(t *testing.T) {
 func TestEncoder_buildQuery_ReturnError(t *testing.T) {
 	encoder := NewEncoder()
 	encoder.err = errors.New("return")
-	encoder.buildQuery(reflect.ValueOf("s"), "", reflect.Int)
+	encoder.buildQuery(reflect.ValueOf("s"), "", reflect.Int, nil)
 	if encoder.err == nil || encoder.err.Error() != "return" {
 		t.Error("unmatched error")
 	}
@@ -220,6 +223,289 @@ func TestEncoder_RegisterEncodeFunc(t *testing.T) {
 	}
 }
 
+type testTextLevel int
+
+func (l testTextLevel) MarshalText() ([]byte, error) {
+	switch l {
+	case 1:
+		return []byte("low"), nil
+	case 2:
+		return []byte("high"), nil
+	}
+	return nil, errors.New("unknown level")
+}
+
+func (l *testTextLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type testTextPoint struct {
+	X, Y int
+}
+
+func (p *testTextPoint) MarshalText() ([]byte, error) {
+	return []byte(strconv.Itoa(p.X) + ":" + strconv.Itoa(p.Y)), nil
+}
+
+func (p *testTextPoint) UnmarshalText(text []byte) error {
+	arr := strings.SplitN(string(text), ":", 2)
+	if len(arr) != 2 {
+		return errors.New("invalid point")
+	}
+	p.X, _ = strconv.Atoi(arr[0])
+	p.Y, _ = strconv.Atoi(arr[1])
+	return nil
+}
+
+type testTextData struct {
+	Level    testTextLevel   `query:"level"`
+	Point    testTextPoint   `query:"point"`
+	PointPtr *testTextPoint  `query:"pointPtr"`
+	Levels   []testTextLevel `query:"levels"`
+}
+
+func TestEncoder_Marshal_TextMarshaler(t *testing.T) {
+	data := testTextData{
+		Level:    2,
+		Point:    testTextPoint{X: 1, Y: 2},
+		PointPtr: &testTextPoint{X: 3, Y: 4},
+		Levels:   []testTextLevel{1, 2},
+	}
+	bytes, err := NewEncoder(WithQueryEncoder(&errorQueryEncoder{})).Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if string(bytes) != "level=high&point=1:2&pointPtr=3:4&levels[]=low&levels[]=high" {
+		t.Error("failed to Marshal TextMarshaler", string(bytes))
+	}
+}
+
+func TestEncoder_Marshal_TextMarshalerError(t *testing.T) {
+	data := testTextData{Level: 3}
+	_, err := Marshal(data)
+	if _, ok := err.(ErrTranslated); !ok {
+		t.Error("unmatched error")
+	}
+}
+
+type testTimeData struct {
+	Since     time.Time                `query:"since,layout=2006-01-02"`
+	At        time.Time                `query:"at"`
+	Unix      time.Time                `query:"unix,unix"`
+	UnixMilli *time.Time               `query:"milli,unixmilli"`
+	Timeout   time.Duration            `query:"timeout"`
+	Days      []time.Time              `query:"days,layout=20060102"`
+	Empty     time.Time                `query:"empty"`
+	Delays    map[string]time.Duration `query:"delays"`
+}
+
+func TestEncoder_Marshal_Time(t *testing.T) {
+	at := time.Date(2021, 3, 4, 5, 6, 7, 8000000, time.UTC)
+	data := testTimeData{
+		Since:     at,
+		At:        at,
+		Unix:      at,
+		UnixMilli: &at,
+		Timeout:   90 * time.Minute,
+		Days:      []time.Time{at, at.AddDate(0, 0, 1)},
+	}
+	bytes, err := NewEncoder(WithQueryEncoder(&errorQueryEncoder{})).Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if string(bytes) != "since=2021-03-04&at=2021-03-04T05:06:07.008Z&unix=1614834367&milli=1614834367008"+
+		"&timeout=1h30m0s&days[]=20210304&days[]=20210305" {
+		t.Error("failed to Marshal time", string(bytes))
+		return
+	}
+
+	v := testTimeData{}
+	err = NewParser(WithQueryEncoder(&errorQueryEncoder{errorAt: 100})).Unmarshal(bytes, &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !v.Since.Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)) || !v.At.Equal(at) ||
+		!v.Unix.Equal(at.Truncate(time.Second)) || v.UnixMilli == nil || !v.UnixMilli.Equal(at) {
+		t.Error("failed to Unmarshal time", v)
+	}
+	if v.Timeout != 90*time.Minute || len(v.Days) != 2 || v.Days[1].Day() != 5 || !v.Empty.IsZero() {
+		t.Error("failed to Unmarshal time", v)
+	}
+}
+
+type testLimit int
+
+func (l testLimit) IsZero() bool {
+	return l < 0
+}
+
+type testEmptyData struct {
+	Flag  int           `query:"flag,keepempty"`
+	Page  int           `query:"page,omitempty"`
+	Name  string        `query:"name"`
+	Limit testLimit     `query:"limit,omitzero,keepempty"`
+	Child *builderChild `query:"child,omitempty"`
+	At    time.Time     `query:"at,omitzero"`
+}
+
+func TestEncoder_Marshal_EmptyOptions(t *testing.T) {
+	data := testEmptyData{Limit: -1}
+	bytes, err := Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(bytes) != "flag=0" {
+		t.Error("failed to Marshal with empty options", string(bytes))
+	}
+
+	bytes, err = NewEncoder(WithNeedEmptyValue(true)).Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(bytes) != "flag=0&name=" {
+		t.Error("failed to Marshal with empty options and needEmptyValue", string(bytes))
+	}
+
+	data = testEmptyData{Flag: 1, Page: 2, Limit: 0, At: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	bytes, err = Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(bytes) != "flag=1&page=2&limit=0&at=2021-01-01T00%3A00%3A00Z" {
+		t.Error("failed to Marshal with empty options", string(bytes))
+	}
+}
+
+func TestEncoder_Marshal_OmitZeroPointer(t *testing.T) {
+	var v struct {
+		P *time.Time `query:"p,omitzero"`
+		L *testLimit `query:"l,omitzero"`
+	}
+	at, limit := time.Time{}, testLimit(-1)
+	v.P, v.L = &at, &limit
+	bytes, err := NewEncoder(WithNeedEmptyValue(true)).Marshal(v)
+	if err != nil || string(bytes) != "" {
+		t.Error("failed to omit pointer whose pointed value is zero", string(bytes), err)
+	}
+
+	at, limit = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), 5
+	bytes, err = NewEncoder(WithNeedEmptyValue(true)).Marshal(v)
+	if err != nil || string(bytes) != "p=2021-01-01T00%3A00%3A00Z&l=5" {
+		t.Error("failed to Marshal pointer whose pointed value is not zero", string(bytes), err)
+	}
+}
+
+func TestEncoder_MarshalValues(t *testing.T) {
+	data := testRepeatData{Tags: []string{"a b", "c&d"}, Ids: [2]int{1, 2}, Role: "admin"}
+	data.Child.Names = []string{"x"}
+	values, err := MarshalValues(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := url.Values{
+		"tag[]":         {"a b", "c&d"},
+		"id[]":          {"1", "2"},
+		"child[name][]": {"x"},
+		"role":          {"admin"},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Error("failed to MarshalValues", values)
+	}
+
+	values, err = NewEncoder(WithArrayFormat(ArrayFormatRepeat)).MarshalValues(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(values["tag"], []string{"a b", "c&d"}) {
+		t.Error("failed to MarshalValues with repeat format", values)
+	}
+
+	values, err = MarshalValues(map[string]complex64{"d": complex(1, 2)})
+	if _, ok := err.(ErrUnhandledType); !ok || values != nil {
+		t.Error("unmatched error")
+	}
+}
+
+func TestEncoder_Marshal_SortedMapKeys(t *testing.T) {
+	data := map[interface{}]interface{}{
+		10:    "a",
+		2:     "b",
+		"z":   map[int]int{100: 1, 9: 2, -1: 3},
+		"a b": []string{"x"},
+		true:  1,
+	}
+	encoder := NewEncoder(WithSortedMapKeys(), WithQueryEncoder(&errorQueryEncoder{}))
+	expected := "1=1&2=b&10=a&a b[]=x&z[-1]=3&z[9]=2&z[100]=1"
+	for i := 0; i < 10; i++ {
+		bytes, err := encoder.Marshal(data)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if string(bytes) != expected {
+			t.Error("failed to Marshal with sorted map keys", string(bytes))
+			return
+		}
+	}
+
+	//keys of mixed kinds
+	mixed := map[interface{}]string{2: "a", 10: "b", "15": "c", 3: "d", "100": "e", 25: "f"}
+	expected = "2=a&3=d&10=b&25=f&100=e&15=c"
+	for i := 0; i < 200; i++ {
+		bytes, err := encoder.Marshal(mixed)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if string(bytes) != expected {
+			t.Error("failed to Marshal with sorted mixed map keys", string(bytes))
+			return
+		}
+	}
+}
+
+func Test_isZeroField(t *testing.T) {
+	var child *builderChild
+	if !isZeroField(reflect.ValueOf(child)) {
+		t.Error("nil pointer should be zero")
+	}
+	if isZeroField(reflect.ValueOf(&builderChild{})) {
+		t.Error("non-nil pointer should not be zero")
+	}
+	if !isZeroField(reflect.ValueOf(testLimit(-1))) || isZeroField(reflect.ValueOf(testLimit(0))) {
+		t.Error("IsZero method should take priority")
+	}
+
+	limit := testLimit(-1)
+	if !isZeroField(reflect.ValueOf(&limit)) || !isZeroField(reflect.ValueOf(&time.Time{})) {
+		t.Error("IsZero method of pointed value should be checked")
+	}
+	limit = 0
+	if isZeroField(reflect.ValueOf(&limit)) {
+		t.Error("non-zero pointed value should not be zero")
+	}
+}
+
 //BenchmarkMarshal-4     	  295726	     11902 ns/op
 func BenchmarkMarshal(b *testing.B) {
 	data := getMockData2()
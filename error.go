@@ -3,6 +3,7 @@ package urlquery
 import (
 	"reflect"
 	"strconv"
+	"strings"
 )
 
 // An ErrUnhandledType is a customized error
@@ -56,3 +57,127 @@ type ErrInvalidMapValueType struct {
 func (e ErrInvalidMapValueType) Error() string {
 	return "failed to handle map value type(" + e.typ.String() + ")"
 }
+
+// An ErrDuplicateKey is a customized error
+type ErrDuplicateKey struct {
+	key string
+}
+
+func (e ErrDuplicateKey) Error() string {
+	return "failed to handle duplicate key(" + e.key + ")"
+}
+
+// Key return the repeated key of query
+func (e ErrDuplicateKey) Key() string {
+	return e.key
+}
+
+// An ErrUnknownKeys is a customized error
+type ErrUnknownKeys struct {
+	keys []string
+}
+
+func (e ErrUnknownKeys) Error() string {
+	return "failed to handle unknown keys(" + strings.Join(e.keys, ",") + ")"
+}
+
+// Keys return all the unknown keys of query, sorted
+func (e ErrUnknownKeys) Keys() []string {
+	return e.keys
+}
+
+// An ErrBodyTooLarge is a customized error
+type ErrBodyTooLarge struct {
+	limit int64
+}
+
+func (e ErrBodyTooLarge) Error() string {
+	return "failed to read body larger than limit(" + strconv.FormatInt(e.limit, 10) + ")"
+}
+
+// Limit return the size cap of body
+func (e ErrBodyTooLarge) Limit() int64 {
+	return e.limit
+}
+
+// An ErrLimitExceeded is a customized error
+type ErrLimitExceeded struct {
+	name  string
+	limit int64
+}
+
+func (e ErrLimitExceeded) Error() string {
+	return "failed to handle query exceeding limit(" + e.name + "=" + strconv.FormatInt(e.limit, 10) + ")"
+}
+
+// Name return the name of exceeded limit, eg. MaxBytes
+func (e ErrLimitExceeded) Name() string {
+	return e.name
+}
+
+// Limit return the value of exceeded limit
+func (e ErrLimitExceeded) Limit() int64 {
+	return e.limit
+}
+
+// An ErrSparseArray is a customized error
+type ErrSparseArray struct {
+	key   string
+	index int
+}
+
+func (e ErrSparseArray) Error() string {
+	return "failed to handle sparse array key(" + e.key + ") missing index(" + strconv.Itoa(e.index) + ")"
+}
+
+// Key return the key of slice
+func (e ErrSparseArray) Key() string {
+	return e.key
+}
+
+// Index return the first missing index
+func (e ErrSparseArray) Index() int {
+	return e.index
+}
+
+// A DecodeError describes the query value which failed to be decoded.
+// Use errors.As to get it, and errors.Unwrap to get the underlying error.
+type DecodeError struct {
+	// Key is the full query key as sent by client, eg. filter[items][3][qty] or filter.items.3.qty with KeyStyleDots.
+	// Elements of ids[] or ids=1,2 are reported with ids[] or ids, instead of repacked ids[1]
+	Key string
+	// Field is the go struct field path, eg. Filter.Items[3].Qty
+	Field string
+	// Type is the target type of value
+	Type reflect.Type
+	// Value is the raw value of query
+	Value string
+	// Err is the underlying error
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "failed to decode key(" + e.Key + ") field(" + e.Field + "):" + e.Err.Error()
+}
+
+// Unwrap return the underlying error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeErrors is a collection of errors, returned by parser with WithCollectErrors option.
+// Each of them is a *DecodeError, except ErrUnknownKeys.
+type DecodeErrors []error
+
+func (e DecodeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap return all the errors, so that errors.Is and errors.As can check each of them
+func (e DecodeErrors) Unwrap() []error {
+	return e
+}
@@ -51,3 +51,55 @@ func TestErrInvalidMapValueType_Error(t *testing.T) {
 		t.Error(err.Error())
 	}
 }
+
+func TestErrDuplicateKey_Error(t *testing.T) {
+	err := ErrDuplicateKey{key: "role"}
+	if err.Error() != "failed to handle duplicate key(role)" {
+		t.Error(err.Error())
+	}
+	if err.Key() != "role" {
+		t.Error("key is wrong")
+	}
+}
+
+func TestErrUnknownKeys_Error(t *testing.T) {
+	err := ErrUnknownKeys{keys: []string{"a", "b[c]"}}
+	if err.Error() != "failed to handle unknown keys(a,b[c])" {
+		t.Error(err.Error())
+	}
+	if len(err.Keys()) != 2 {
+		t.Error("keys is wrong")
+	}
+}
+
+func TestDecodeError_Error(t *testing.T) {
+	err1 := errors.New("new")
+	err := &DecodeError{Key: "a[0]", Field: "A[0]", Type: reflect.TypeOf(0), Value: "x", Err: err1}
+	if err.Error() != "failed to decode key(a[0]) field(A[0]):new" {
+		t.Error(err.Error())
+	}
+	if !errors.Is(err, err1) {
+		t.Error("failed to unwrap")
+	}
+}
+
+func TestDecodeErrors_Error(t *testing.T) {
+	err1 := errors.New("a")
+	err := DecodeErrors{err1, errors.New("b")}
+	if err.Error() != "a; b" {
+		t.Error(err.Error())
+	}
+	if !errors.Is(err, err1) || len(err.Unwrap()) != 2 {
+		t.Error("failed to unwrap")
+	}
+}
+
+func TestErrSparseArray_Error(t *testing.T) {
+	err := ErrSparseArray{key: "ids", index: 2}
+	if err.Error() != "failed to handle sparse array key(ids) missing index(2)" {
+		t.Error(err.Error())
+	}
+	if err.Key() != "ids" || err.Index() != 2 {
+		t.Error("key or index is wrong")
+	}
+}
@@ -14,6 +14,9 @@ go run ${curDir}/struct_anonymous.go
 echo "\n converter:"
 go run ${curDir}/converter.go
 
+echo "\n type converter:"
+go run ${curDir}/type_converter.go
+
 echo "\n option:"
 go run ${curDir}/withoption.go
 
@@ -34,7 +34,7 @@ func main() {
 		return
 	}
 
-	//remember: just id and name can be parsed
+	//remember: just id and name can be parsed into map[string]string
 	//arr[0] is not match map[string]string
 	fmt.Println(m)
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hetiansu5/urlquery"
+	"reflect"
+	"strings"
+)
+
+// A Currency is test type
+type Currency string
+
+// A PriceData is test structure
+type PriceData struct {
+	Name     string   `query:"name"`
+	Currency Currency `query:"currency"`
+}
+
+func main() {
+	data := PriceData{
+		Name:     "apple",
+		Currency: "usd",
+	}
+
+	//converters just affect Currency type, not all the strings
+	converters := urlquery.NewConverters()
+	converters.RegisterType(reflect.TypeOf(Currency("")), func(rv reflect.Value) string {
+		return strings.ToUpper(rv.String())
+	}, func(s string) (reflect.Value, error) {
+		return reflect.ValueOf(Currency(strings.ToLower(s))), nil
+	})
+
+	//Marshal: from go structure to url query string
+	encoder := urlquery.NewEncoder(urlquery.WithConverters(converters))
+	bytes, err := encoder.Marshal(data)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(bytes))
+
+	//Unmarshal: from url query string to go structure
+	parser := urlquery.NewParser(urlquery.WithConverters(converters))
+	v := &PriceData{}
+	err = parser.Unmarshal(bytes, v)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(*v)
+}
@@ -0,0 +1,73 @@
+package urlquery
+
+import (
+	"reflect"
+	"sync"
+)
+
+// cache of struct fields, map[reflect.Type][]field
+// it is shared across all the encoders and parsers
+var fieldCache sync.Map
+
+// A field is resolved metadata of struct field
+type field struct {
+	// index of field in struct
+	index int
+	// go field name
+	fieldName string
+	// query key name, tag name or field name
+	name string
+	tag  *tag
+	// anonymous field
+	anonymous bool
+	// anonymous struct field, whose fields are promoted to parent
+	embedded  bool
+	exported  bool
+	omitEmpty bool
+	omitZero  bool
+}
+
+// get cached fields of struct type, fields ignored by tag `query:"-"` are excluded
+func getFields(typ reflect.Type) []field {
+	if fields, ok := fieldCache.Load(typ); ok {
+		return fields.([]field)
+	}
+
+	fields := make([]field, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		ft := typ.Field(i)
+		f := field{
+			index:     i,
+			fieldName: ft.Name,
+			anonymous: ft.Anonymous,
+			exported:  ft.PkgPath == "",
+		}
+
+		//specially handle anonymous fields
+		if ft.Anonymous && ft.Type.Kind() == reflect.Struct {
+			f.embedded = true
+			fields = append(fields, f)
+			continue
+		}
+
+		tag := ft.Tag.Get("query")
+		//all ignore
+		if tag == "-" {
+			continue
+		}
+
+		t := newTag(tag)
+		//get the related name
+		f.name = t.getName()
+		if f.name == "" {
+			f.name = ft.Name
+		}
+		f.tag = t
+		f.omitEmpty = t.contains("omitempty")
+		f.omitZero = t.contains("omitzero")
+		fields = append(fields, f)
+	}
+
+	actual, _ := fieldCache.LoadOrStore(typ, fields)
+	return actual.([]field)
+}
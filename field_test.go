@@ -0,0 +1,108 @@
+package urlquery
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testBenchChild struct {
+	Code  string `query:"code"`
+	Level int    `query:"level,omitempty"`
+}
+
+type testBenchData struct {
+	testBenchChild
+	Id       int            `query:"id"`
+	Name     string         `query:"name"`
+	Email    string         `query:"email,omitempty"`
+	Age      uint8          `query:"age"`
+	Score    float64        `query:"score"`
+	Active   bool           `query:"active"`
+	Tags     []string       `query:"tags,comma"`
+	Owner    testBenchChild `query:"owner"`
+	Manager  testBenchChild `query:"manager,omitzero"`
+	Page     int            `query:"page,keepempty"`
+	Size     int            `query:"size"`
+	Sort     string         `query:"sort"`
+	Ignored  string         `query:"-"`
+	internal string
+}
+
+func getBenchData() testBenchData {
+	return testBenchData{
+		testBenchChild: testBenchChild{Code: "c", Level: 1},
+		Id:             1,
+		Name:           "name",
+		Email:          "a@b.c",
+		Age:            20,
+		Score:          9.5,
+		Active:         true,
+		Tags:           []string{"a", "b"},
+		Owner:          testBenchChild{Code: "o", Level: 2},
+		Manager:        testBenchChild{Code: "m"},
+		Size:           20,
+		Sort:           "id",
+	}
+}
+
+func Test_getFields(t *testing.T) {
+	typ := reflect.TypeOf(testBenchData{})
+	fields := getFields(typ)
+
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	expected := []string{"", "id", "name", "email", "age", "score", "active", "tags", "owner", "manager",
+		"page", "size", "sort", "internal"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Error("unmatched field names", names)
+	}
+
+	if !fields[0].embedded || fields[0].fieldName != "testBenchChild" || fields[0].tag != nil {
+		t.Error("unmatched embedded field", fields[0])
+	}
+	if f := fields[3]; f.index != 3 || f.fieldName != "Email" || !f.omitEmpty || f.omitZero {
+		t.Error("unmatched field", f)
+	}
+	if f := fields[9]; !f.omitZero || f.tag.getName() != "manager" {
+		t.Error("unmatched field", f)
+	}
+	if f := fields[13]; f.exported || f.index != 14 {
+		t.Error("unmatched unexported field", f)
+	}
+
+	//cached fields are shared
+	if &getFields(typ)[0] != &fields[0] {
+		t.Error("fields should be cached")
+	}
+}
+
+//BenchmarkMarshal_Struct   	   20000	      5884 ns/op	    1768 B/op	      63 allocs/op
+//without field cache:      	   20000	      9860 ns/op	    3304 B/op	     134 allocs/op
+func BenchmarkMarshal_Struct(b *testing.B) {
+	data := getBenchData()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, err := Marshal(data)
+		if err != nil {
+			b.Error(err)
+		}
+	}
+}
+
+//BenchmarkUnmarshal_Struct 	   20000	     13949 ns/op	    7264 B/op	     113 allocs/op
+//without field cache:      	   20000	     17880 ns/op	    8592 B/op	     151 allocs/op
+func BenchmarkUnmarshal_Struct(b *testing.B) {
+	data, _ := Marshal(getBenchData())
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		v := testBenchData{}
+		err := Unmarshal(data, &v)
+		if err != nil {
+			b.Error(err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package urlquery
+
+// An ArrayFormat is the style of encoding slice and array into URL Query string
+type ArrayFormat int
+
+const (
+	// ArrayFormatBrackets eg. ids[]=1&ids[]=2, it is default
+	ArrayFormatBrackets ArrayFormat = iota
+	// ArrayFormatIndices eg. ids[0]=1&ids[1]=2
+	ArrayFormatIndices
+	// ArrayFormatRepeat eg. ids=1&ids=2
+	ArrayFormatRepeat
+	// ArrayFormatComma eg. ids=1,2
+	ArrayFormatComma
+	// ArrayFormatPipe eg. ids=1|2
+	ArrayFormatPipe
+	// ArrayFormatSpace eg. ids=1+2
+	ArrayFormatSpace
+)
+
+// A KeyStyle is the notation of nested key in URL Query string
+type KeyStyle int
+
+const (
+	// KeyStyleBrackets eg. filter[owner][name]=x&items[0][id]=5, it is default
+	KeyStyleBrackets KeyStyle = iota
+	// KeyStyleDots eg. filter.owner.name=x&items.0.id=5
+	KeyStyleDots
+)
+
+// A DuplicateKeyPolicy decides which value is used when a key is repeated but the target is a single value
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeyLast uses the last value, it is default
+	DuplicateKeyLast DuplicateKeyPolicy = iota
+	// DuplicateKeyFirst uses the first value
+	DuplicateKeyFirst
+	// DuplicateKeyError rejects the repeated key with ErrDuplicateKey
+	DuplicateKeyError
+	// DuplicateKeyJoin joins all the values with comma, eg. role=user&role=admin -> user,admin
+	DuplicateKeyJoin
+)
+
+// A SparseArrayMode decides how slice is decoded when indices of query keys are not contiguous, eg. ids[0]=1&ids[5000]=2
+type SparseArrayMode int
+
+const (
+	// SparseArrayKeep places elements at their indices, and gaps are filled with zero values, it is default
+	SparseArrayKeep SparseArrayMode = iota
+	// SparseArrayCompact keeps elements in order of indices without gaps, eg. ids[3]=a&ids[1]=b -> [b a]
+	SparseArrayCompact
+	// SparseArrayReject rejects the slice with gaps by ErrSparseArray
+	SparseArrayReject
+)
+
+var (
+	// relation between tag option and array format
+	arrayFormatOptions = map[string]ArrayFormat{
+		"brackets": ArrayFormatBrackets,
+		"indices":  ArrayFormatIndices,
+		"repeat":   ArrayFormatRepeat,
+		"comma":    ArrayFormatComma,
+		"pipe":     ArrayFormatPipe,
+		"space":    ArrayFormatSpace,
+	}
+)
+
+// get separator of array format which joins all elements into a single value
+// empty string means that each element is a single pair
+func (f ArrayFormat) separator() string {
+	switch f {
+	case ArrayFormatComma:
+		return ","
+	case ArrayFormatPipe:
+		return "|"
+	case ArrayFormatSpace:
+		return " "
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,202 @@
+package urlquery
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testArrayChild struct {
+	Id int `query:"id"`
+}
+
+type testArrayData struct {
+	Ids      []int            `query:"ids"`
+	Names    [2]string        `query:"names"`
+	Children []testArrayChild `query:"children"`
+}
+
+func TestArrayFormat(t *testing.T) {
+	data := testArrayData{
+		Ids:      []int{1, 2, 3},
+		Names:    [2]string{"a", "b"},
+		Children: []testArrayChild{{Id: 4}},
+	}
+	expects := map[ArrayFormat]string{
+		ArrayFormatBrackets: "ids[]=1&ids[]=2&ids[]=3&names[]=a&names[]=b&children[0][id]=4",
+		ArrayFormatIndices:  "ids[0]=1&ids[1]=2&ids[2]=3&names[0]=a&names[1]=b&children[0][id]=4",
+		ArrayFormatRepeat:   "ids=1&ids=2&ids=3&names=a&names=b&children[0][id]=4",
+		ArrayFormatComma:    "ids=1,2,3&names=a,b&children[0][id]=4",
+		ArrayFormatPipe:     "ids=1|2|3&names=a|b&children[0][id]=4",
+		ArrayFormatSpace:    "ids=1 2 3&names=a b&children[0][id]=4",
+	}
+
+	for format, expect := range expects {
+		encoder := NewEncoder(WithArrayFormat(format), WithQueryEncoder(&errorQueryEncoder{}))
+		bytes, err := encoder.Marshal(data)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(bytes) != expect {
+			t.Error("failed to Marshal with array format", format, string(bytes))
+			continue
+		}
+
+		v := testArrayData{}
+		parser := NewParser(WithArrayFormat(format), WithQueryEncoder(&errorQueryEncoder{errorAt: 100}))
+		err = parser.Unmarshal(bytes, &v)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(v, data) {
+			t.Error("failed to Unmarshal with array format", format, v)
+		}
+	}
+}
+
+type testArrayTagData struct {
+	Ids    []int     `query:"ids,comma"`
+	Tags   []string  `query:"tags,repeat"`
+	Scores []float32 `query:"scores"`
+	Matrix [][]int   `query:"matrix,pipe"`
+	Ptrs   []*int    `query:"ptrs,comma"`
+	Empty  []int     `query:"empty,comma"`
+}
+
+func TestArrayFormat_Tag(t *testing.T) {
+	one := 1
+	data := testArrayTagData{
+		Ids:    []int{1, 2},
+		Tags:   []string{"a", "b"},
+		Scores: []float32{1.5},
+		Matrix: [][]int{{1, 2}, {3}},
+		Ptrs:   []*int{&one, nil},
+	}
+	encoder := NewEncoder(WithArrayFormat(ArrayFormatIndices), WithQueryEncoder(&errorQueryEncoder{}))
+	bytes, err := encoder.Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(bytes) != "ids=1,2&tags=a&tags=b&scores[0]=1.5&matrix[0]=1|2&matrix[1]=3&ptrs=1" {
+		t.Error("failed to Marshal with array format of tag", string(bytes))
+		return
+	}
+
+	v := testArrayTagData{}
+	parser := NewParser(WithQueryEncoder(&errorQueryEncoder{errorAt: 100}))
+	err = parser.Unmarshal(append(bytes, "&ids[]=3&empty="...), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(v.Ids, []int{3, 1, 2}) || !reflect.DeepEqual(v.Tags, data.Tags) ||
+		!reflect.DeepEqual(v.Scores, data.Scores) || !reflect.DeepEqual(v.Matrix, data.Matrix) {
+		t.Error("failed to Unmarshal with array format of tag", v)
+	}
+	if len(v.Ptrs) != 1 || *v.Ptrs[0] != 1 || v.Empty != nil {
+		t.Error("failed to Unmarshal with array format of tag", v)
+	}
+}
+
+func TestArrayFormat_TopLevel(t *testing.T) {
+	bytes, err := NewEncoder(WithArrayFormat(ArrayFormatComma)).Marshal([]string{"a", "b"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(bytes) != "0=a&1=b" {
+		t.Error("top-level slice should not be joined", string(bytes))
+	}
+}
+
+type testKeyStyleOwner struct {
+	Name string `query:"name"`
+}
+
+type testKeyStyleData struct {
+	Filter struct {
+		Owner testKeyStyleOwner `query:"owner"`
+	} `query:"filter"`
+	Items []testArrayChild `query:"items"`
+	Ids   []int            `query:"ids"`
+	Price testMoney        `query:"price"`
+	Hosts map[string]int   `query:"hosts"`
+}
+
+func TestKeyStyle_Dots(t *testing.T) {
+	data := testKeyStyleData{
+		Items: []testArrayChild{{Id: 5}},
+		Ids:   []int{1, 2},
+		Price: testMoney{Amount: 3, Currency: "USD"},
+	}
+	data.Filter.Owner.Name = "x"
+
+	encoder := NewEncoder(WithKeyStyle(KeyStyleDots), WithQueryEncoder(&errorQueryEncoder{}))
+	bytes, err := encoder.Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(bytes) != "filter.owner.name=x&items.0.id=5&ids[]=1&ids[]=2&price[amount]=3&price[currency]=USD" {
+		t.Error("failed to Marshal with dot-notation", string(bytes))
+		return
+	}
+
+	v := testKeyStyleData{}
+	parser := NewParser(WithKeyStyle(KeyStyleDots), WithQueryEncoder(&errorQueryEncoder{errorAt: 100}))
+	err = parser.Unmarshal(bytes, &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(v, data) {
+		t.Error("failed to Unmarshal with dot-notation", v)
+	}
+}
+
+func TestKeyStyle_Mixed(t *testing.T) {
+	data := "filter[owner].name=x&items.0[id]=5&items[1].id=6&ids.1=2&hosts[example.com]=1"
+	v := testKeyStyleData{}
+	parser := NewParser(WithKeyStyle(KeyStyleDots), WithQueryEncoder(&errorQueryEncoder{errorAt: 100}))
+	err := parser.Unmarshal([]byte(data), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if v.Filter.Owner.Name != "x" || len(v.Items) != 2 || v.Items[1].Id != 6 ||
+		!reflect.DeepEqual(v.Ids, []int{0, 2}) || v.Hosts["example.com"] != 1 {
+		t.Error("failed to Unmarshal with mixed notation", v)
+	}
+}
+
+func TestKeyStyle_DotsInKey(t *testing.T) {
+	data := map[string]map[string]int{
+		"emails": {"a@b.com": 1, "c": 2},
+		"v1.2":   {"x.y.z": 3},
+	}
+
+	for _, encoder := range []*encoder{NewEncoder(WithKeyStyle(KeyStyleDots)), NewEncoder(WithKeyStyle(KeyStyleDots), WithCanonical())} {
+		bytes, err := encoder.Marshal(data)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		v := map[string]map[string]int{}
+		err = NewParser(WithKeyStyle(KeyStyleDots)).Unmarshal(bytes, &v)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(v, data) {
+			t.Error("failed to round trip key containing dot", string(bytes), v)
+		}
+	}
+
+	bytes, _ := NewEncoder(WithKeyStyle(KeyStyleDots), WithSortedMapKeys(), WithQueryEncoder(&errorQueryEncoder{})).Marshal(data)
+	if string(bytes) != "emails[a@b.com]=1&emails.c=2&[v1.2][x.y.z]=3" {
+		t.Error("key containing dot should be wrapped in brackets", string(bytes))
+	}
+}
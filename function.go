@@ -1,12 +1,18 @@
 package urlquery
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 var (
+	zeroCheckerType = reflect.TypeOf((*zeroChecker)(nil)).Elem()
+
 	//
 	accessMapTypes = map[reflect.Kind]bool{
 		reflect.Bool:    true,
@@ -27,6 +33,11 @@ var (
 	}
 )
 
+// A zeroChecker is a type reporting whether it is zero value by itself, eg. time.Time
+type zeroChecker interface {
+	IsZero() bool
+}
+
 // check if reflect.Kind of map's key is valid
 func isAccessMapKeyType(kind reflect.Kind) bool {
 	_, ok := accessMapTypes[kind]
@@ -34,8 +45,62 @@ func isAccessMapKeyType(kind reflect.Kind) bool {
 }
 
 // check if reflect.Kind of map's value is valid
+// value is parsed recursively, so just the kinds which can never be decoded are invalid
 func isAccessMapValueType(kind reflect.Kind) bool {
-	return isAccessMapKeyType(kind)
+	switch kind {
+	case reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return false
+	default:
+		return true
+	}
+}
+
+// get implementation of interface type by value or pointer of value
+// pointer and interface are dereferenced by caller, and then the element is checked
+func getImplementation(rv reflect.Value, typ reflect.Type) (interface{}, bool) {
+	if !rv.IsValid() || !rv.CanInterface() || rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		return nil, false
+	}
+
+	if rv.Type().Implements(typ) {
+		return rv.Interface(), true
+	}
+
+	if reflect.PtrTo(rv.Type()).Implements(typ) {
+		//value is not addressable, so copy it to get a pointer
+		if !rv.CanAddr() {
+			ptr := reflect.New(rv.Type())
+			ptr.Elem().Set(rv)
+			rv = ptr.Elem()
+		}
+		return rv.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+// dereference pointer and interface, return invalid value if it is nil
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+// check if value may be handled by a type-keyed converter
+// pointer and interface are dereferenced by caller, and then the element is checked
+func isConvertibleKind(rv reflect.Value) bool {
+	return rv.IsValid() && rv.Kind() != reflect.Ptr && rv.Kind() != reflect.Interface
+}
+
+// check if pointer of type implements interface type, which can be used to decode value
+func isPtrImplementation(typ reflect.Type, iface reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Interface {
+		return false
+	}
+	return reflect.PtrTo(typ).Implements(iface)
 }
 
 // students[0][id] -> students, [0][id]
@@ -58,27 +123,161 @@ func unpackQueryKey(key string) (pre, suf string) {
 	return key, ""
 }
 
-// if key like `students[0]` , repack it to `students[]`
-func repackArrayQueryKey(key string) string {
-	l := len(key)
-	if l > 0 && key[l-1] == ']' {
-		for l--; l >= 0; l-- {
-			if key[l] == '[' {
-				return key[:l+1] + "]"
-			}
-		}
+// generate next parent node key
+func genNextParentNode(parentNode, key string) string {
+	if len(parentNode) > 0 {
+		return parentNode + "[" + key + "]"
 	}
 	return key
 }
 
-// generate next parent node key
-func genNextParentNode(parentNode, key string) string {
-	if len(parentNode) > 0 {
+// generate next parent node key with dot-notation
+// key containing dot is wrapped in brackets, so that it is kept as a single segment, eg. emails[a@b.com]
+func genNextDotParentNode(parentNode, key string) string {
+	if strings.Contains(key, ".") {
 		return parentNode + "[" + key + "]"
 	}
+	if len(parentNode) > 0 {
+		return parentNode + "." + key
+	}
 	return key
 }
 
+// repack dot-notation key to bracket-notation, dots inside brackets are kept
+// eg. filter.owner.name -> filter[owner][name], items.0[id] -> items[0][id], m[a.b] -> m[a.b]
+// leading segment in brackets is unwrapped, eg. [a.b][c] -> a.b[c]
+func dotsToBrackets(key string) string {
+	if !strings.Contains(key, ".") {
+		return key
+	}
+
+	buf := make([]byte, 0, len(key)+4)
+	depth := 0
+	inSegment := false
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c == '.' && depth == 0:
+			if inSegment {
+				buf = append(buf, ']')
+			}
+			buf = append(buf, '[')
+			inSegment = true
+			continue
+		case c == '[':
+			if inSegment && depth == 0 {
+				buf = append(buf, ']')
+				inSegment = false
+			}
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		}
+		buf = append(buf, c)
+	}
+	if inSegment {
+		buf = append(buf, ']')
+	}
+
+	if buf[0] == '[' {
+		if i := bytes.IndexByte(buf, ']'); i > 1 {
+			return string(buf[1:i]) + string(buf[i+1:])
+		}
+	}
+	return string(buf)
+}
+
+// repack bracket-notation key to dot-notation, segment containing dot is kept in brackets
+// eg. filter[owner][name] -> filter.owner.name, emails[a@b.com] -> emails[a@b.com]
+func bracketsToDots(key string) string {
+	pre, suf := unpackQueryKey(key)
+	if suf == "" || suf[0] != '[' {
+		return key
+	}
+
+	parentNode := genNextDotParentNode("", pre)
+	for suf != "" {
+		//the remaining is not nested, keep the whole key as it is
+		if suf[0] != '[' {
+			return key
+		}
+		pre, suf = unpackQueryKey(suf)
+		parentNode = genNextDotParentNode(parentNode, pre)
+	}
+	return parentNode
+}
+
+// compare map keys by rank of kind first, then by value within the same rank, so that the order is transitive.
+// numeric keys are compared numerically, NaN is ordered before other floats,
+// keys of other kinds or equal values of different kinds are compared by encoded strings
+func lessMapKey(x, y reflect.Value, xs, ys string) bool {
+	rx, ry := mapKeyRank(x.Kind()), mapKeyRank(y.Kind())
+	if rx != ry {
+		return rx < ry
+	}
+
+	switch rx {
+	case 0:
+		if x.Bool() != y.Bool() {
+			return !x.Bool()
+		}
+	case 1:
+		if x.Int() != y.Int() {
+			return x.Int() < y.Int()
+		}
+	case 2:
+		if x.Uint() != y.Uint() {
+			return x.Uint() < y.Uint()
+		}
+	case 3:
+		xf, yf := x.Float(), y.Float()
+		if math.IsNaN(xf) || math.IsNaN(yf) {
+			if math.IsNaN(xf) != math.IsNaN(yf) {
+				return math.IsNaN(xf)
+			}
+		} else if xf != yf {
+			return xf < yf
+		}
+	case 4:
+		if x.String() != y.String() {
+			return x.String() < y.String()
+		}
+	}
+	return xs < ys
+}
+
+// get rank of map key kind, kinds compared in the same way share a rank
+func mapKeyRank(kind reflect.Kind) int {
+	switch kind {
+	case reflect.Bool:
+		return 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return 1
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return 2
+	case reflect.Float32, reflect.Float64:
+		return 3
+	case reflect.String:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// get sorted numeric indices, ok is false if any key is not a non-negative integer in canonical form
+func sortIndices(keys map[string]bool) ([]int, bool) {
+	indices := make([]int, 0, len(keys))
+	for k := range keys {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || strconv.Itoa(i) != k {
+			return nil, false
+		}
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices, true
+}
+
 // check if value is zero-value
 func isZeroValue(v reflect.Value) bool {
 	switch v.Kind() {
@@ -117,3 +316,24 @@ func isZeroValue(v reflect.Value) bool {
 		panic(fmt.Sprintf("panic at isZeroValue %v", v.Kind()))
 	}
 }
+
+// check if field is zero-value, IsZero method of the field takes priority
+func isZeroField(v reflect.Value) bool {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return true
+	}
+	//IsZero method is checked on pointed value of non-nil pointer, eg. *time.Time
+	if v.Kind() == reflect.Ptr {
+		if v.CanInterface() && v.Type().Implements(zeroCheckerType) {
+			return v.Interface().(zeroChecker).IsZero()
+		}
+		if z, ok := getImplementation(v.Elem(), zeroCheckerType); ok {
+			return z.(zeroChecker).IsZero()
+		}
+		return false
+	}
+	if z, ok := getImplementation(v, zeroCheckerType); ok {
+		return z.(zeroChecker).IsZero()
+	}
+	return isZeroValue(v)
+}
@@ -1,6 +1,8 @@
 package urlquery
 
 import (
+	"fmt"
+	"math"
 	"reflect"
 	"testing"
 )
@@ -33,37 +35,58 @@ func Test_unpackQueryKey_LeftSquareBracketEnd(t *testing.T) {
 	}
 }
 
-func Test_repackArrayQueryKey(t *testing.T) {
-	key := "[hts][0]"
-	target := repackArrayQueryKey(key)
-	if target != "[hts][]" {
-		t.Error("failed to execute repackArrayQueryKey function")
+func Test_genNextParentNode(t *testing.T) {
+	if genNextParentNode("", "test") != "test" {
+		t.Error("failed to execute genNextParentNode")
 	}
-}
 
-func Test_repackArrayQueryKey1(t *testing.T) {
-	key := "hts]"
-	target := repackArrayQueryKey(key)
-	if target != "hts]" {
-		t.Error("failed to execute repackArrayQueryKey function")
+	if genNextParentNode("p", "test") != "p[test]" {
+		t.Error("failed to execute genNextParentNode")
 	}
 }
 
-func Test_repackArrayQueryKey2(t *testing.T) {
-	key := "[hts"
-	target := repackArrayQueryKey(key)
-	if target != "[hts" {
-		t.Error("failed to execute repackArrayQueryKey function")
+func Test_genNextDotParentNode(t *testing.T) {
+	if genNextDotParentNode("", "test") != "test" || genNextDotParentNode("p", "test") != "p.test" {
+		t.Error("failed to execute genNextDotParentNode")
+	}
+	if genNextDotParentNode("", "a.b") != "[a.b]" || genNextDotParentNode("p", "a.b") != "p[a.b]" {
+		t.Error("failed to execute genNextDotParentNode with dot")
 	}
 }
 
-func Test_genNextParentNode(t *testing.T) {
-	if genNextParentNode("", "test") != "test" {
-		t.Error("failed to execute genNextParentNode")
+func Test_dotsToBrackets(t *testing.T) {
+	cases := map[string]string{
+		"hts":               "hts",
+		"filter.owner.name": "filter[owner][name]",
+		"items.0[id]":       "items[0][id]",
+		"items[0].id":       "items[0][id]",
+		"m[a.b].c":          "m[a.b][c]",
+		"[a.b][c]":          "a.b[c]",
+		"[a.b].c":           "a.b[c]",
+	}
+	for key, target := range cases {
+		if dotsToBrackets(key) != target {
+			t.Error("failed to execute dotsToBrackets function", key)
+		}
 	}
+}
 
-	if genNextParentNode("p", "test") != "p[test]" {
-		t.Error("failed to execute genNextParentNode")
+func Test_bracketsToDots(t *testing.T) {
+	cases := map[string]string{
+		"hts":                   "hts",
+		"filter[owner][name]":   "filter.owner.name",
+		"items[0][id]":          "items.0.id",
+		"emails[a@b.com]":       "emails[a@b.com]",
+		"m[a.b][c]":             "m[a.b].c",
+		"a.b[c]":                "[a.b].c",
+		"a[b]c":                 "a[b]c",
+		"[a][b]":                "a.b",
+		"filter[items][3][qty]": "filter.items.3.qty",
+	}
+	for key, target := range cases {
+		if s := bracketsToDots(key); s != target {
+			t.Error("failed to execute bracketsToDots function", key, s)
+		}
 	}
 }
 
@@ -142,3 +165,38 @@ func Test_isZeroValue_UndefinedType(t *testing.T) {
 	}()
 	isZeroValue(reflect.ValueOf(a))
 }
+
+func Test_lessMapKey(t *testing.T) {
+	cases := []struct {
+		x, y     interface{}
+		expected bool
+	}{
+		{2, 10, true},
+		{10, 2, false},
+		{int8(-1), int8(1), true},
+		{uint(2), uint(10), true},
+		{1.5, 10.25, true},
+		{false, true, true},
+		{true, false, false},
+		{"b", "a", false},
+		{"A", "a", true},
+		{1, "a", true},
+		{10, "15", true},
+		{"15", 2, false},
+		{int8(3), 2, false},
+		{int64(2), 10, true},
+		{true, 0, true},
+		{uint(1), 2, false},
+		{2.5, uint(1), false},
+		{math.NaN(), 1.0, true},
+		{1.0, math.NaN(), false},
+		{math.NaN(), math.NaN(), false},
+	}
+	for _, c := range cases {
+		x, y := reflect.ValueOf(c.x), reflect.ValueOf(c.y)
+		xs, ys := fmt.Sprint(c.x), fmt.Sprint(c.y)
+		if lessMapKey(x, y, xs, ys) != c.expected {
+			t.Error("unmatched result of lessMapKey", c.x, c.y)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package urlquery
+
+import (
+	"strings"
+)
+
+// Limits is resource limits of parser, to protect public endpoints from large or hostile query.
+// Zero value of field means no limit. Parser returns ErrLimitExceeded naming the exceeded field.
+type Limits struct {
+	// MaxParams is max count of key-value pairs
+	MaxParams int
+	// MaxDepth is max count of nested segments of key, eg. depth of a[b][c] is 3
+	MaxDepth int
+	// MaxArrayIndex is max index of slice element, eg. ids[100]=1, which avoids allocating huge slice.
+	// In SparseArrayCompact mode, it limits position of element instead of index in query
+	MaxArrayIndex int
+	// MaxKeyLength is max length of unescaped key
+	MaxKeyLength int
+	// MaxValueLength is max length of unescaped value
+	MaxValueLength int
+	// MaxTotalBytes is max bytes of query string, BindRequest raises it to cover form body capped by WithFormBody
+	MaxTotalBytes int64
+}
+
+// DefaultLimits return the limits used by parser if it is not specified by WithLimits
+func DefaultLimits() Limits {
+	return Limits{
+		MaxParams:      10000,
+		MaxDepth:       32,
+		MaxArrayIndex:  10000,
+		MaxKeyLength:   1024,
+		MaxValueLength: 1 << 20,
+		MaxTotalBytes:  DefaultMaxBodySize,
+	}
+}
+
+// check limits of raw key-value pair, params is count of pairs including it
+func (l Limits) checkPair(key, value string, params int) error {
+	if l.MaxParams > 0 && params > l.MaxParams {
+		return ErrLimitExceeded{name: "MaxParams", limit: int64(l.MaxParams)}
+	}
+	if l.MaxKeyLength > 0 && len(key) > l.MaxKeyLength {
+		return ErrLimitExceeded{name: "MaxKeyLength", limit: int64(l.MaxKeyLength)}
+	}
+	if l.MaxValueLength > 0 && len(value) > l.MaxValueLength {
+		return ErrLimitExceeded{name: "MaxValueLength", limit: int64(l.MaxValueLength)}
+	}
+	return nil
+}
+
+// check limit of depth of key in bracket-notation
+func (l Limits) checkDepth(key string) error {
+	if l.MaxDepth > 0 && strings.Count(key, "[")+1 > l.MaxDepth {
+		return ErrLimitExceeded{name: "MaxDepth", limit: int64(l.MaxDepth)}
+	}
+	return nil
+}
+
+// check limit of array index
+func (l Limits) checkArrayIndex(i int) error {
+	if l.MaxArrayIndex > 0 && i > l.MaxArrayIndex {
+		return ErrLimitExceeded{name: "MaxArrayIndex", limit: int64(l.MaxArrayIndex)}
+	}
+	return nil
+}
+
+// check limit of total bytes
+func (l Limits) checkTotalBytes(n int64) error {
+	if l.MaxTotalBytes > 0 && n > l.MaxTotalBytes {
+		return ErrLimitExceeded{name: "MaxTotalBytes", limit: l.MaxTotalBytes}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package urlquery
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParser_Unmarshal_Limits(t *testing.T) {
+	limits := Limits{
+		MaxParams:      3,
+		MaxDepth:       2,
+		MaxArrayIndex:  5,
+		MaxKeyLength:   8,
+		MaxValueLength: 4,
+		MaxTotalBytes:  32,
+	}
+	cases := []struct {
+		data  string
+		name  string
+		limit int64
+	}{
+		{"a=1&b=2&c=3&d=4", "MaxParams", 3},
+		{"a[b][c]=1", "MaxDepth", 2},
+		{"a[6]=1", "MaxArrayIndex", 5},
+		{"abcdefghi=1", "MaxKeyLength", 8},
+		{"a=12345", "MaxValueLength", 4},
+		{"a=" + strings.Repeat("%20", 11), "MaxTotalBytes", 32},
+	}
+
+	for _, c := range cases {
+		v := map[string][]int{}
+		err := NewParser(WithLimits(limits)).Unmarshal([]byte(encodeSquareBracket(c.data)), &v)
+		if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != c.name || e.Limit() != c.limit {
+			t.Error("unmatched error", c.data, err)
+		}
+	}
+
+	v := map[string][]int{}
+	err := NewParser(WithLimits(Limits{MaxArrayIndex: 1})).Unmarshal([]byte(encodeSquareBracket("a[]=1&a[]=2&a[]=3")), &v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxArrayIndex" {
+		t.Error("unmatched error of appended key", err)
+	}
+
+	err = NewParser(WithLimits(limits)).Unmarshal([]byte(encodeSquareBracket("a[5]=1&b[]=2&c=1234")), &v)
+	if err != nil || len(v["a"]) != 6 || len(v["b"]) != 1 || len(v["c"]) != 1 {
+		t.Error("query within limits should be decoded", v, err)
+	}
+}
+
+func TestParser_Unmarshal_DefaultLimits(t *testing.T) {
+	l := DefaultLimits()
+	data := encodeSquareBracket("ids[" + strconv.Itoa(l.MaxArrayIndex+1) + "]=1")
+	v := struct {
+		Ids []int `query:"ids"`
+	}{}
+
+	err := Unmarshal([]byte(data), &v)
+	var de *DecodeError
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxArrayIndex" || !errors.As(err, &de) || de.Key != "ids" {
+		t.Error("unmatched error", err)
+	}
+	if v.Ids != nil {
+		t.Error("slice should not be allocated", len(v.Ids))
+	}
+
+	err = NewParser(WithLimits(Limits{})).Unmarshal([]byte(data), &v)
+	if err != nil || len(v.Ids) != l.MaxArrayIndex+2 {
+		t.Error("zero limits means no limit", err)
+	}
+
+	err = Unmarshal([]byte(encodeSquareBracket("ids[-1]=1")), &v)
+	if e := (*strconv.NumError)(nil); !errors.As(err, &e) || e.Err != strconv.ErrRange {
+		t.Error("unmatched error", err)
+	}
+
+	err = Unmarshal([]byte(strings.Repeat("[a]", l.MaxDepth)+"=1"), &v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxDepth" {
+		t.Error("unmatched error", err)
+	}
+}
+
+func TestDecoder_Decode_Limits(t *testing.T) {
+	v := map[string]string{}
+	err := NewDecoder(strings.NewReader("a=1&b=2"), WithLimits(Limits{MaxTotalBytes: 6})).Decode(&v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxTotalBytes" || e.Limit() != 6 {
+		t.Error("unmatched error", err)
+	}
+
+	err = NewDecoder(strings.NewReader("a=1&b=2"), WithLimits(Limits{MaxParams: 1})).Decode(&v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxParams" {
+		t.Error("unmatched error", err)
+	}
+
+	err = NewDecoder(strings.NewReader("a=1&b=2"), WithLimits(Limits{})).Decode(&v)
+	if err != nil || len(v) != 2 {
+		t.Error("zero limits means no limit", v, err)
+	}
+}
+
+func TestParser_Unmarshal_LimitsExpandedValues(t *testing.T) {
+	type data struct {
+		Ids   []int    `query:"ids,comma"`
+		Fixed [3]int   `query:"fixed,comma"`
+		Tags  []string `query:"tags"`
+	}
+
+	cases := []struct {
+		query  string
+		limits Limits
+		name   string
+	}{
+		{"ids=1,2,3", Limits{MaxArrayIndex: 1}, "MaxArrayIndex"},
+		{"fixed=1,2,3", Limits{MaxArrayIndex: 1}, "MaxArrayIndex"},
+		{"ids=1,2,3&tags=a", Limits{MaxParams: 3}, "MaxParams"},
+		{"tags=a&tags=b&tags=c", Limits{MaxArrayIndex: 1}, "MaxArrayIndex"},
+	}
+	for _, c := range cases {
+		v := data{}
+		err := NewParser(WithLimits(c.limits)).Unmarshal([]byte(c.query), &v)
+		if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != c.name {
+			t.Error("unmatched error", c.query, err)
+		}
+	}
+
+	v := data{}
+	err := NewParser(WithLimits(Limits{MaxParams: 4, MaxArrayIndex: 2})).Unmarshal([]byte("ids=1,2,3&tags=a"), &v)
+	if err != nil || len(v.Ids) != 3 {
+		t.Error("expanded values within limits should be decoded", v, err)
+	}
+
+	//hostile value is rejected before all the elements are allocated
+	large := []byte("ids=" + strings.Repeat("1,", 500000) + "1")
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err = Unmarshal(large, &v)
+	runtime.ReadMemStats(&after)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) {
+		t.Error("unmatched error", err)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 64<<20 {
+		t.Error("too much memory is allocated", n)
+	}
+}
+
+func TestParser_Limits_TotalBytes(t *testing.T) {
+	limits := WithLimits(Limits{MaxTotalBytes: 10})
+	v := map[string]string{}
+
+	r := httptest.NewRequest(http.MethodGet, "/?a="+strings.Repeat("x", 98), nil)
+	err := BindRequest(r, &v, limits)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxTotalBytes" {
+		t.Error("unmatched error", err)
+	}
+
+	err = NewParser(limits).UnmarshalValues(url.Values{"a": {"1234"}, "b": {"1234"}}, &v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxTotalBytes" {
+		t.Error("unmatched error", err)
+	}
+
+	//a=123&b=12 is just 10 bytes
+	err = NewParser(limits).UnmarshalValues(url.Values{"a": {"123"}, "b": {"12"}}, &v)
+	if err != nil || v["a"] != "123" || v["b"] != "12" {
+		t.Error("values within limit should be decoded", v, err)
+	}
+}
+
+func TestParser_Limits_DotKeyLength(t *testing.T) {
+	//a.b.c is repacked to a[b][c] which is longer than the limit
+	v := map[string]map[string]map[string]int{}
+	parser := NewParser(WithKeyStyle(KeyStyleDots), WithLimits(Limits{MaxKeyLength: 5}))
+	err := parser.Unmarshal([]byte("a.b.c=1"), &v)
+	if err != nil || v["a"]["b"]["c"] != 1 {
+		t.Error("length of raw key should be checked", v, err)
+	}
+
+	err = parser.Unmarshal([]byte("a.b.cd=1"), &v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxKeyLength" {
+		t.Error("unmatched error", err)
+	}
+}
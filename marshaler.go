@@ -0,0 +1,39 @@
+package urlquery
+
+import (
+	"net/url"
+	"reflect"
+)
+
+var (
+	marshalerType   = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+)
+
+// A Marshaler is a type which owns its entire URL Query representation, like json.Marshaler.
+// prefix is the key of value, eg. `filter[box]`, empty at top level.
+// Keys of returned values are written as they are, so they should be generated with prefix.
+type Marshaler interface {
+	MarshalQuery(prefix string) (url.Values, error)
+}
+
+// An Unmarshaler is a type which can decode its own URL Query representation, like json.Unmarshaler.
+// values contains all the pairs whose key is prefix itself or nested under prefix.
+// Keys of values and prefix are always in bracket-notation, even if parser accepts dot-notation.
+type Unmarshaler interface {
+	UnmarshalQuery(prefix string, values url.Values) error
+}
+
+// get Marshaler implemented by value or pointer of value
+func getMarshaler(rv reflect.Value) (Marshaler, bool) {
+	m, ok := getImplementation(rv, marshalerType)
+	if !ok {
+		return nil, false
+	}
+	return m.(Marshaler), true
+}
+
+// check if type can be decoded via Unmarshaler of its pointer
+func isUnmarshaler(typ reflect.Type) bool {
+	return isPtrImplementation(typ, unmarshalerType)
+}
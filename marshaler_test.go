@@ -0,0 +1,115 @@
+package urlquery
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testMoney struct {
+	Amount   int64
+	Currency string
+}
+
+func (m *testMoney) MarshalQuery(prefix string) (url.Values, error) {
+	if m.Currency == "" {
+		return nil, errors.New("currency is required")
+	}
+	return url.Values{
+		prefix + "[amount]":   {strconv.FormatInt(m.Amount, 10)},
+		prefix + "[currency]": {m.Currency},
+	}, nil
+}
+
+func (m *testMoney) UnmarshalQuery(prefix string, values url.Values) error {
+	amount, err := strconv.ParseInt(values.Get(prefix+"[amount]"), 10, 64)
+	if err != nil {
+		return err
+	}
+	m.Amount = amount
+	m.Currency = values.Get(prefix + "[currency]")
+	return nil
+}
+
+type testSort []string
+
+func (s testSort) MarshalQuery(prefix string) (url.Values, error) {
+	return url.Values{prefix: {strings.Join(s, ",")}}, nil
+}
+
+func (s *testSort) UnmarshalQuery(prefix string, values url.Values) error {
+	*s = strings.Split(values.Get(prefix), ",")
+	return nil
+}
+
+type testMarshalerData struct {
+	Id       int        `query:"id"`
+	Price    testMoney  `query:"price"`
+	Discount *testMoney `query:"discount"`
+	Sort     testSort   `query:"sort"`
+}
+
+func TestMarshaler_Marshal(t *testing.T) {
+	data := testMarshalerData{
+		Id:    1,
+		Price: testMoney{Amount: 100, Currency: "USD"},
+		Sort:  testSort{"-created", "name"},
+	}
+	bytes, err := NewEncoder(WithQueryEncoder(&errorQueryEncoder{})).Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if string(bytes) != "id=1&price[amount]=100&price[currency]=USD&sort=-created,name" {
+		t.Error("failed to Marshal Marshaler", string(bytes))
+		return
+	}
+
+	v := testMarshalerData{}
+	err = Unmarshal([]byte(encodeSquareBracket(string(bytes))), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v.Id != 1 || v.Price != data.Price || v.Discount != nil || len(v.Sort) != 2 || v.Sort[0] != "-created" {
+		t.Error("failed to Unmarshal Unmarshaler", v)
+	}
+}
+
+func TestMarshaler_MapValue(t *testing.T) {
+	data := map[string]testMoney{"min": {Amount: 5, Currency: "EUR"}}
+	bytes, err := NewEncoder(WithQueryEncoder(&errorQueryEncoder{})).Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(bytes) != "min[amount]=5&min[currency]=EUR" {
+		t.Error("failed to Marshal Marshaler of map value", string(bytes))
+	}
+}
+
+func TestMarshaler_TopLevel(t *testing.T) {
+
+	v := testSort{}
+	err := Unmarshal([]byte("=a,b"), &v)
+	if err != nil || len(v) != 2 || v[1] != "b" {
+		t.Error("failed to Unmarshal top-level Unmarshaler", err, v)
+	}
+}
+
+func TestMarshaler_Error(t *testing.T) {
+	_, err := Marshal(testMarshalerData{})
+	if _, ok := err.(ErrTranslated); !ok {
+		t.Error("unmatched error")
+	}
+
+	v := testMarshalerData{}
+	err = Unmarshal([]byte(encodeSquareBracket("discount[amount]=x")), &v)
+	if e := (ErrTranslated{}); !errors.As(err, &e) {
+		t.Error("unmatched error")
+	}
+}
@@ -2,8 +2,24 @@ package urlquery
 
 // options for encoder or parser
 type options struct {
-	queryEncoder   QueryEncoder
-	needEmptyValue bool
+	queryEncoder        QueryEncoder
+	needEmptyValue      bool
+	converters          *Converters
+	arrayFormat         ArrayFormat
+	keyStyle            KeyStyle
+	duplicateKeyPolicy  DuplicateKeyPolicy
+	disallowUnknownKeys bool
+	collectErrors       bool
+	formBody            bool
+	maxBodySize         int64
+	bindPrecedence      BindPrecedence
+	maxBytes            int64
+	maxPairs            int
+	sortedMapKeys       bool
+	canonical           bool
+	maxArrayLength      int
+	limits              *Limits
+	sparseArrayMode     SparseArrayMode
 }
 
 // An Option is a func type for applying diff options
@@ -19,8 +35,145 @@ func WithQueryEncoder(u QueryEncoder) Option {
 // WithNeedEmptyValue is supposed to control whether to ignore zero value.
 // It just happen to the element directly in structure, not including map slice array
 // default:false, meaning ignore zero-value
+// It can be overridden per field by tag options: omitempty, omitzero and keepempty
 func WithNeedEmptyValue(c bool) Option {
 	return func(ops *options) {
 		ops.needEmptyValue = c
 	}
 }
+
+// WithConverters is supposed to share type-keyed converters between encoder and parser.
+// Converters registered via encoder or parser are also put into it.
+func WithConverters(c *Converters) Option {
+	return func(ops *options) {
+		ops.converters = c
+	}
+}
+
+// WithArrayFormat is supposed to specify the style of slice and array, which is used by both encoder and parser.
+// It can be overridden per field by tag option, eg. `query:"ids,comma"`
+// default: ArrayFormatBrackets
+func WithArrayFormat(f ArrayFormat) Option {
+	return func(ops *options) {
+		ops.arrayFormat = f
+	}
+}
+
+// WithKeyStyle is supposed to specify the notation of nested key, which is used by both encoder and parser.
+// Parser with KeyStyleDots accepts both dot-notation and bracket-notation.
+// default: KeyStyleBrackets
+func WithKeyStyle(s KeyStyle) Option {
+	return func(ops *options) {
+		ops.keyStyle = s
+	}
+}
+
+// WithDuplicateKeyPolicy is supposed to decide which value is used when a key is repeated but the target is a single value.
+// Repeated key is always decoded into all the elements of slice or array.
+// DuplicateKeyError can reject HTTP parameter pollution like `role=user&role=admin` for security-sensitive endpoints.
+// default: DuplicateKeyLast
+func WithDuplicateKeyPolicy(policy DuplicateKeyPolicy) Option {
+	return func(ops *options) {
+		ops.duplicateKeyPolicy = policy
+	}
+}
+
+// WithDisallowUnknownKeys is supposed to reject keys which are not consumed by any field, like json.Decoder.DisallowUnknownFields.
+// Parser returns ErrUnknownKeys listing all of them. It just happens to parser.
+func WithDisallowUnknownKeys() Option {
+	return func(ops *options) {
+		ops.disallowUnknownKeys = true
+	}
+}
+
+// WithCollectErrors is supposed to keep parsing after failing to decode a value, and fill what it can.
+// Parser returns DecodeErrors listing every failing key and reason. It just happens to parser.
+func WithCollectErrors() Option {
+	return func(ops *options) {
+		ops.collectErrors = true
+	}
+}
+
+// WithFormBody is supposed to make BindRequest decode application/x-www-form-urlencoded body besides query string.
+// Body larger than maxBytes is rejected with ErrBodyTooLarge, non-positive maxBytes means DefaultMaxBodySize.
+// MaxTotalBytes of Limits is raised to maxBytes plus length of query string for the merged values, if it is smaller.
+// It just happens to BindRequest.
+func WithFormBody(maxBytes int64) Option {
+	return func(ops *options) {
+		ops.formBody = true
+		ops.maxBodySize = maxBytes
+	}
+}
+
+// WithBindPrecedence is supposed to decide which source wins when a key appears in both query string and form body.
+// It just happens to BindRequest.
+// default: BindQueryFirst
+func WithBindPrecedence(precedence BindPrecedence) Option {
+	return func(ops *options) {
+		ops.bindPrecedence = precedence
+	}
+}
+
+// WithMaxBytes is supposed to limit total bytes read by decoder, non-positive n means MaxTotalBytes of Limits.
+// Decoder returns ErrLimitExceeded once the input exceeds it. It just happens to decoder.
+func WithMaxBytes(n int64) Option {
+	return func(ops *options) {
+		ops.maxBytes = n
+	}
+}
+
+// WithMaxPairs is supposed to limit count of key-value pairs read by decoder, non-positive n means no limit.
+// Decoder returns ErrLimitExceeded once the input exceeds it. It just happens to decoder.
+func WithMaxPairs(n int) Option {
+	return func(ops *options) {
+		ops.maxPairs = n
+	}
+}
+
+// WithSortedMapKeys is supposed to encode map entries in a deterministic order, instead of random order of map.
+// Numeric and bool keys are compared by value, eg. 2 < 10, and string keys are compared byte-wise.
+// Keys of mixed kinds are grouped in order of bool, int, uint, float, string and others, eg. 2 < 10 < "100" < "15".
+// It just happens to encoder.
+func WithSortedMapKeys() Option {
+	return func(ops *options) {
+		ops.sortedMapKeys = true
+	}
+}
+
+// WithCanonical is supposed to encode in canonical form, which is used for request signing.
+// Keys and values are encoded by RFC3986QueryEncoder, overriding WithQueryEncoder,
+// and pairs are sorted byte-wise by escaped key and then by value, as Canonicalize does.
+// Zero-valued struct fields are still dropped as usual, use it with WithNeedEmptyValue(true) or keepempty tag to keep them.
+// It just happens to encoder, except MarshalValues.
+func WithCanonical() Option {
+	return func(ops *options) {
+		ops.canonical = true
+	}
+}
+
+// WithMaxArrayLength is supposed to limit count of elements appended per prefix, by `[]` keys, eg. ids[]=1&ids[]=2,
+// or by repeated plain keys and separated values, eg. ids=1&ids=2 | ids=1,2. Indexed keys like ids[5]=1 are not counted.
+// Parser returns ErrLimitExceeded once it is exceeded, non-positive n means no limit. It just happens to parser.
+func WithMaxArrayLength(n int) Option {
+	return func(ops *options) {
+		ops.maxArrayLength = n
+	}
+}
+
+// WithLimits is supposed to specify resource limits of parser, zero value of field means no limit.
+// Parser returns ErrLimitExceeded once any of them is exceeded. It just happens to parser.
+// default: DefaultLimits()
+func WithLimits(l Limits) Option {
+	return func(ops *options) {
+		ops.limits = &l
+	}
+}
+
+// WithSparseArrayMode is supposed to decide how slice is decoded when indices of query keys are not contiguous.
+// Use map[int]T as target to keep both indices and values of sparse data. It just happens to parser.
+// default: SparseArrayKeep
+func WithSparseArrayMode(mode SparseArrayMode) Option {
+	return func(ops *options) {
+		ops.sparseArrayMode = mode
+	}
+}
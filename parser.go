@@ -2,16 +2,35 @@ package urlquery
 
 import (
 	"bytes"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 )
 
+// counter of elements appended to a prefix
+type appendCounter struct {
+	// count of appended elements
+	count int
+	// next index to be tried
+	next int
+}
+
 // parser from URL Query string to go structure
 type parser struct {
-	container     map[string]string
+	container     map[string][]string
+	root          *keyNode
+	appended      map[string]*appendCounter
+	params        int
+	limits        Limits
+	consumed      map[string]bool
+	rawKeys       map[string]string
+	fieldPath     []string
+	decoded       int
 	err           error
+	errs          []error
 	opts          options
 	mutex         sync.Mutex
 	queryEncoder  QueryEncoder
@@ -26,11 +45,26 @@ func NewParser(opts ...Option) *parser {
 		option(&p.opts)
 	}
 	p.decodeFuncMap = make(map[reflect.Kind]valueDecode)
+	if p.opts.converters == nil {
+		p.opts.converters = NewConverters()
+	}
+	p.limits = DefaultLimits()
+	if p.opts.limits != nil {
+		p.limits = *p.opts.limits
+	}
 	return p
 }
 
 // handle string data to a map structure for the next parsing
-func (p *parser) init(data []byte) (err error) {
+func (p *parser) init(data []byte) error {
+	if err := p.limits.checkTotalBytes(int64(len(data))); err != nil {
+		return err
+	}
+	return p.split(data, p.add)
+}
+
+// split string data into unescaped key-value pairs, pair without `=` is ignored
+func (p *parser) split(data []byte, fn func(key, value string) error) (err error) {
 	arr := bytes.Split(data, []byte(SymbolAnd))
 	for _, value := range arr {
 		ns := strings.SplitN(string(value), SymbolEqual, 2)
@@ -45,25 +79,121 @@ func (p *parser) init(data []byte) (err error) {
 				return
 			}
 
-			//If last two characters of key equal `[]`, repack it to `[{i++}]`
-			l := len(ns[0])
-			if l > 2 && ns[0][l-2:] == "[]" {
-				//limit iteration to avoid attack of large or dead circle
-				for i := 0; i < 1000000; i++ {
-					tKey := ns[0][:l-2] + "[" + strconv.Itoa(i) + "]"
-					if _, ok := p.container[tKey]; !ok {
-						ns[0] = tKey
-						break
-					}
-				}
+			err = fn(ns[0], ns[1])
+			if err != nil {
+				return
 			}
-
-			p.container[ns[0]] = ns[1]
 		}
 	}
 	return
 }
 
+// handle url.Values to a map structure for the next parsing, keys and values are already unescaped
+func (p *parser) initValues(values url.Values) error {
+	//sort keys to make repacking of `[]` keys stable
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	//total bytes are counted as joined query string, without escaping
+	var total int64
+	for _, k := range keys {
+		for _, v := range values[k] {
+			total += int64(len(k) + len(v) + 2)
+		}
+	}
+	if total > 0 {
+		total--
+	}
+	if err := p.limits.checkTotalBytes(total); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if err := p.add(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// add unescaped key-value pair into container
+func (p *parser) add(key, value string) error {
+	//length of key is checked before it is repacked
+	p.params++
+	if err := p.limits.checkPair(key, value, p.params); err != nil {
+		return err
+	}
+
+	//dot-notation is repacked to bracket-notation, so that both of them are accepted
+	raw := key
+	if p.opts.keyStyle == KeyStyleDots {
+		key = dotsToBrackets(key)
+	}
+	if err := p.limits.checkDepth(key); err != nil {
+		return err
+	}
+
+	//If last two characters of key equal `[]`, repack it to `[{i++}]`
+	l := len(key)
+	if l > 2 && key[l-2:] == "[]" {
+		prefix := key[:l-2]
+		if err := p.countAppended(prefix); err != nil {
+			return err
+		}
+
+		//next index of prefix only increases, skipping indices already used by indexed keys
+		c := p.appended[prefix]
+		i := c.next
+		for p.has(prefix + "[" + strconv.Itoa(i) + "]") {
+			i++
+		}
+		if err := p.limits.checkArrayIndex(i); err != nil {
+			return err
+		}
+		c.next = i + 1
+		key = prefix + "[" + strconv.Itoa(i) + "]"
+	}
+
+	//the key sent by client is kept for reporting errors
+	if key != raw || p.opts.keyStyle == KeyStyleDots {
+		if _, ok := p.rawKeys[key]; !ok {
+			p.rawKeys[key] = raw
+		}
+	}
+
+	//values of repeated key are kept in order
+	p.set(key, append(p.container[key], value))
+	return nil
+}
+
+// count elements appended to prefix, by `[]` keys or expanded values, which are limited by WithMaxArrayLength
+func (p *parser) countAppended(prefix string) error {
+	c, ok := p.appended[prefix]
+	if !ok {
+		c = &appendCounter{}
+		p.appended[prefix] = c
+	}
+
+	c.count++
+	if p.opts.maxArrayLength > 0 && c.count > p.opts.maxArrayLength {
+		return ErrLimitExceeded{name: "MaxArrayLength", limit: int64(p.opts.maxArrayLength)}
+	}
+	return nil
+}
+
+// set values of key into container, new key is inserted into trie
+func (p *parser) set(key string, values []string) {
+	if _, ok := p.container[key]; !ok {
+		p.root.insert(key)
+	}
+	p.container[key] = values
+}
+
 // reset specified query encoder
 func (p *parser) resetQueryEncoder() {
 	if p.opts.queryEncoder != nil {
@@ -79,33 +209,57 @@ func (p *parser) genNextParentNode(parentNode, key string) string {
 }
 
 // iteratively parse go structure from string
-func (p *parser) parse(rv reflect.Value, parentNode string) {
+func (p *parser) parse(rv reflect.Value, parentNode string, t *tag) {
 	if p.err != nil {
 		return
 	}
 
+	//value of type with registered or built-in converter is decoded from a single value
+	if isConvertibleKind(rv) && p.getTypeDecodeFunc(rv.Type(), t) != nil {
+		p.parseValue(rv, parentNode, t)
+		return
+	}
+
+	//value implementing Unmarshaler decodes its entire query representation
+	if rv.IsValid() && isUnmarshaler(rv.Type()) {
+		p.parseForUnmarshaler(rv, parentNode)
+		return
+	}
+
+	//value implementing encoding.TextUnmarshaler is decoded from a single value
+	if rv.IsValid() && isTextUnmarshaler(rv.Type()) {
+		p.parseValue(rv, parentNode, t)
+		return
+	}
+
 	switch rv.Kind() {
 	case reflect.Ptr:
-		p.parseForPrt(rv, parentNode)
+		p.parseForPrt(rv, parentNode, t)
 	case reflect.Interface:
-		p.parse(rv.Elem(), parentNode)
+		p.parseForInterface(rv, parentNode, t)
 	case reflect.Map:
-		p.parseForMap(rv, parentNode)
+		p.parseForMap(rv, parentNode, t)
 	case reflect.Array:
+		if err := p.expandArrayValues(parentNode, t); err != nil {
+			p.addError(p.newDecodeError(parentNode, rv.Type(), "", err))
+			return
+		}
 		for i := 0; i < rv.Cap(); i++ {
-			p.parse(rv.Index(i), p.genNextParentNode(parentNode, strconv.Itoa(i)))
+			p.pushField("[" + strconv.Itoa(i) + "]")
+			p.parse(rv.Index(i), p.genNextParentNode(parentNode, strconv.Itoa(i)), t)
+			p.popField()
 		}
 	case reflect.Slice:
-		p.parseForSlice(rv, parentNode)
+		p.parseForSlice(rv, parentNode, t)
 	case reflect.Struct:
 		p.parseForStruct(rv, parentNode)
 	default:
-		p.parseValue(rv, parentNode)
+		p.parseValue(rv, parentNode, t)
 	}
 }
 
 // parse for pointer value
-func (p *parser) parseForPrt(rv reflect.Value, parentNode string) {
+func (p *parser) parseForPrt(rv reflect.Value, parentNode string, t *tag) {
 	//If Ptr is nil and can be set, Ptr should be initialized
 	if rv.IsNil() {
 		if rv.CanSet() {
@@ -118,15 +272,97 @@ func (p *parser) parseForPrt(rv reflect.Value, parentNode string) {
 			}
 
 			rv.Set(reflect.New(rv.Type().Elem()))
-			p.parse(rv.Elem(), parentNode)
+			p.parse(rv.Elem(), parentNode, t)
 		}
 	} else {
-		p.parse(rv.Elem(), parentNode)
+		p.parse(rv.Elem(), parentNode, t)
+	}
+}
+
+// parse for interface value
+// If it holds a pointer, parse into the pointed value. Empty interface is replaced with generic value
+func (p *parser) parseForInterface(rv reflect.Value, parentNode string, t *tag) {
+	if !rv.IsNil() && (rv.Elem().Kind() == reflect.Ptr || rv.Type().NumMethod() != 0) {
+		p.parse(rv.Elem(), parentNode, t)
+		return
+	}
+
+	if !rv.CanSet() || rv.Type().NumMethod() != 0 {
+		return
+	}
+
+	if v, ok := p.parseGeneric(parentNode); ok {
+		rv.Set(reflect.ValueOf(v))
+	}
+}
+
+// build generic value from all the keys under prefix, like PHP parse_str
+// leaf is string, repeated key is []interface{} of string
+// node whose children are all numeric indices is []interface{} ordered by index, otherwise map[string]interface{}
+func (p *parser) parseGeneric(parentNode string) (interface{}, bool) {
+	children := p.lookup(parentNode)
+	//the exact key is overridden by nested keys, eg. a=1&a[b]=2
+	delete(children, "")
+
+	if len(children) == 0 {
+		values, ok := p.container[parentNode]
+		if !ok || len(values) == 0 {
+			return nil, false
+		}
+		p.consumed[parentNode] = true
+		p.decoded++
+
+		if len(values) == 1 {
+			return values[0], true
+		}
+		list := make([]interface{}, len(values))
+		for i, v := range values {
+			list[i] = v
+		}
+		return list, true
+	}
+
+	if indices, ok := sortIndices(children); ok {
+		list := make([]interface{}, 0, len(indices))
+		for _, i := range indices {
+			if v, ok := p.parseGeneric(p.genNextParentNode(parentNode, strconv.Itoa(i))); ok {
+				list = append(list, v)
+			}
+		}
+		return list, true
 	}
+
+	m := make(map[string]interface{}, len(children))
+	for k := range children {
+		if v, ok := p.parseGeneric(p.genNextParentNode(parentNode, k)); ok {
+			m[k] = v
+		}
+	}
+	return m, true
+}
+
+// parse for value implementing Unmarshaler
+func (p *parser) parseForUnmarshaler(rv reflect.Value, parentNode string) {
+	if !rv.CanSet() {
+		return
+	}
+
+	values := p.lookupValues(parentNode)
+	// If none match keep zero value
+	if len(values) == 0 {
+		return
+	}
+
+	err := rv.Addr().Interface().(Unmarshaler).UnmarshalQuery(parentNode, values)
+	if err != nil {
+		p.addError(p.newDecodeError(parentNode, rv.Type(), values.Get(parentNode), ErrTranslated{err: err}))
+		return
+	}
+	p.decoded++
 }
 
 // parse for map value
-func (p *parser) parseForMap(rv reflect.Value, parentNode string) {
+func (p *parser) parseForMap(rv reflect.Value, parentNode string, t *tag) {
 	if !rv.CanSet() {
 		return
 	}
@@ -147,116 +383,210 @@ func (p *parser) parseForMap(rv reflect.Value, parentNode string) {
 
 	mapReflect := reflect.MakeMapWithSize(rv.Type(), size)
 	for k := range matches {
-		reflectKey, err := p.decode(rv.Type().Key(), k)
-		if err != nil {
-			p.err = err
+		key := p.genNextParentNode(parentNode, k)
+		p.pushField("[" + k + "]")
+		reflectKey, reflectValue, ok := p.parseMapEntry(rv.Type(), key, k, t)
+		p.popField()
+		if p.err != nil {
 			return
-		}
-
-		value, ok := p.get(p.genNextParentNode(parentNode, k))
-		if !ok {
+		} else if !ok {
 			continue
 		}
 
-		reflectValue, err := p.decode(rv.Type().Elem(), value)
-		if err != nil {
-			p.err = err
-			return
-		}
-
 		mapReflect.SetMapIndex(reflectKey, reflectValue)
 	}
 	rv.Set(mapReflect)
 }
 
+// parse key and value of map entry, value is parsed recursively
+// ok is false if none of value is decoded
+func (p *parser) parseMapEntry(typ reflect.Type, key, k string, t *tag) (reflectKey, reflectValue reflect.Value, ok bool) {
+	reflectKey, err := p.decode(typ.Key(), k, nil)
+	if err != nil {
+		p.addError(p.newDecodeError(key, typ.Key(), k, err))
+		return
+	}
+
+	decoded := p.decoded
+	reflectValue = reflect.New(typ.Elem()).Elem()
+	p.parse(reflectValue, key, t)
+	return reflectKey, reflectValue, p.decoded > decoded
+}
+
 // parse for slice value
-func (p *parser) parseForSlice(rv reflect.Value, parentNode string) {
+func (p *parser) parseForSlice(rv reflect.Value, parentNode string, t *tag) {
 	if !rv.CanSet() {
 		return
 	}
 
+	if err := p.expandArrayValues(parentNode, t); err != nil {
+		p.addError(p.newDecodeError(parentNode, rv.Type(), "", err))
+		return
+	}
+
 	//lookup matched map data with prefix key
 	matches, err := p.lookupForSlice(parentNode)
 	if err != nil {
-		p.err = err
+		p.addError(p.newDecodeError(parentNode, rv.Type(), "", err))
 		return
 	} else if len(matches) == 0 {
 		return
 	}
 
-	//get max cap of slice
-	maxCap := 0
+	indices := make([]int, 0, len(matches))
 	for i := range matches {
-		if i+1 > maxCap {
-			maxCap = i + 1
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	//indices define positions of elements, or just order of them in compact mode
+	size := indices[len(indices)-1] + 1
+	switch p.opts.sparseArrayMode {
+	case SparseArrayCompact:
+		size = len(indices)
+	case SparseArrayReject:
+		for j, i := range indices {
+			if i != j {
+				p.addError(p.newDecodeError(parentNode, rv.Type(), "", ErrSparseArray{key: p.clientKey(parentNode), index: j}))
+				return
+			}
 		}
 	}
 
-	//If slice is nil or cap of slice is less than max cap, slice should be reset correctly
-	if rv.IsNil() || maxCap > rv.Cap() {
-		rv.Set(reflect.MakeSlice(rv.Type(), maxCap, maxCap))
+	//If slice is nil or cap of slice is less than size, slice should be reset correctly
+	if rv.IsNil() || size > rv.Cap() {
+		rv.Set(reflect.MakeSlice(rv.Type(), size, size))
+	} else if size > rv.Len() {
+		rv.SetLen(size)
 	}
 
-	for i := range matches {
-		p.parse(rv.Index(i), p.genNextParentNode(parentNode, strconv.Itoa(i)))
+	for j, i := range indices {
+		pos := i
+		if p.opts.sparseArrayMode == SparseArrayCompact {
+			pos = j
+		}
+		p.pushField("[" + strconv.Itoa(pos) + "]")
+		p.parse(rv.Index(pos), p.genNextParentNode(parentNode, strconv.Itoa(i)), t)
+		p.popField()
 	}
 }
 
 // parse for struct value
 func (p *parser) parseForStruct(rv reflect.Value, parentNode string) {
-	for i := 0; i < rv.NumField(); i++ {
-		ft := rv.Type().Field(i)
-
+	for _, f := range getFields(rv.Type()) {
+		fv := rv.Field(f.index)
 		//specially handle anonymous fields
-		if ft.Anonymous && rv.Field(i).Kind() == reflect.Struct {
-			p.parse(rv.Field(i), parentNode)
-			continue
-		}
-
-		tag := ft.Tag.Get("query")
-		//all ignore
-		if tag == "-" {
+		if f.embedded {
+			p.parse(fv, parentNode, nil)
 			continue
 		}
 
-		t := newTag(tag)
-		name := t.getName()
-		if name == "" {
-			name = ft.Name
-		}
-
-		p.parse(rv.Field(i), p.genNextParentNode(parentNode, name))
+		p.pushField(f.fieldName)
+		p.parse(fv, p.genNextParentNode(parentNode, f.name), f.tag)
+		p.popField()
 	}
 }
 
 // parse text to specified-type value, set into rv
-func (p *parser) parseValue(rv reflect.Value, parentNode string) {
+func (p *parser) parseValue(rv reflect.Value, parentNode string, t *tag) {
 	if !rv.CanSet() {
 		return
 	}
 
-	value, ok := p.get(parentNode)
-	if !ok {
+	value, ok, err := p.get(parentNode)
+	if err != nil {
+		p.addError(p.newDecodeError(parentNode, rv.Type(), value, err))
+		return
+	} else if !ok {
 		return
 	}
 
-	v, err := p.decode(rv.Type(), value)
+	v, err := p.decode(rv.Type(), value, t)
 	if err != nil {
-		p.err = err
+		p.addError(p.newDecodeError(parentNode, rv.Type(), value, err))
 		return
 	}
 
 	rv.Set(v)
+	p.decoded++
 }
 
-// parse text to specified-type value
-func (p *parser) decode(typ reflect.Type, value string) (v reflect.Value, err error) {
-	decodeFunc := p.getDecodeFunc(typ.Kind())
+// parse text to specified-type value, options of tag may affect the format
+func (p *parser) decode(typ reflect.Type, value string, t *tag) (v reflect.Value, err error) {
+	decodeFunc := p.getTypeDecodeFunc(typ, t)
+	if decodeFunc == nil && isTextUnmarshaler(typ) {
+		return textDecode(typ, value)
+	}
+	if decodeFunc == nil {
+		decodeFunc = p.getDecodeFunc(typ.Kind())
+	}
 	if decodeFunc == nil {
 		err = ErrUnhandledType{typ: typ}
 		return
 	}
-	return decodeFunc(value)
+
+	v, err = decodeFunc(value)
+	if err != nil {
+		return
+	}
+	//decoded value of underlying type should be converted to named type, eg. string -> Currency
+	if v.IsValid() && v.Type() != typ && v.Kind() == typ.Kind() && v.Type().ConvertibleTo(typ) {
+		v = v.Convert(typ)
+	}
+	return
+}
+
+// push segment of go field path, eg. Items | [3]
+func (p *parser) pushField(segment string) {
+	if len(p.fieldPath) > 0 && segment[0] != '[' {
+		segment = "." + segment
+	}
+	p.fieldPath = append(p.fieldPath, segment)
+}
+
+// pop the last segment of go field path
+func (p *parser) popField() {
+	p.fieldPath = p.fieldPath[:len(p.fieldPath)-1]
+}
+
+// stop parsing with the error, or collect it and keep going
+func (p *parser) addError(err error) {
+	if p.opts.collectErrors {
+		p.errs = append(p.errs, err)
+	} else {
+		p.err = err
+	}
+}
+
+// make a DecodeError with current go field path
+func (p *parser) newDecodeError(key string, typ reflect.Type, value string, err error) error {
+	return &DecodeError{
+		Key:   p.clientKey(key),
+		Field: strings.Join(p.fieldPath, ""),
+		Type:  typ,
+		Value: value,
+		Err:   err,
+	}
+}
+
+// get key in the notation sent by client, or in the configured key style if it is not sent directly
+// eg. filter.items.3.qty instead of filter[items][3][qty] with KeyStyleDots, ids[] or ids instead of repacked ids[1]
+func (p *parser) clientKey(key string) string {
+	if raw, ok := p.rawKeys[key]; ok {
+		return raw
+	}
+	if p.opts.keyStyle != KeyStyleDots {
+		return key
+	}
+	return bracketsToDots(key)
+}
+
+// get decode function for specified type, registered converter takes priority over built-in one
+func (p *parser) getTypeDecodeFunc(typ reflect.Type, t *tag) valueDecode {
+	if decodeFunc := p.opts.converters.getDecodeFunc(typ); decodeFunc != nil {
+		return decodeFunc
+	}
+	return getTypeDecodeFunc(typ, t)
 }
 
 // get decode function for specified reflect kind
@@ -267,19 +597,42 @@ func (p *parser) getDecodeFunc(kind reflect.Kind) valueDecode {
 	return getDecodeFunc(kind)
 }
 
-// lookup by prefix matching
+// lookup next segments of keys nested under prefix by trie, empty segment means prefix itself is a key
 func (p *parser) lookup(prefix string) map[string]bool {
 	data := map[string]bool{}
-	for k := range p.container {
-		if strings.HasPrefix(k, prefix) {
-			pre, _ := unpackQueryKey(k[len(prefix):])
-			data[pre] = true
-		}
+	if p.has(prefix) {
+		data[""] = true
+	}
+
+	node := p.root.find(prefix)
+	if node == nil {
+		return data
+	}
+	for k := range node.children {
+		data[k] = true
 	}
 	return data
 }
 
-// lookup by prefix matching
+// lookup values whose key is prefix itself or nested under prefix
+func (p *parser) lookupValues(prefix string) url.Values {
+	values := url.Values{}
+	node := p.root.find(prefix)
+	if node == nil {
+		return values
+	}
+
+	node.walk(func(k string) {
+		//key may have been removed by expanding array values
+		if v, ok := p.container[k]; ok {
+			values[k] = append(values[k], v...)
+			p.consumed[k] = true
+		}
+	})
+	return values
+}
+
+// lookup indices of slice elements, which are limited by MaxArrayIndex
 func (p *parser) lookupForSlice(prefix string) (map[int]bool, error) {
 	tmp := p.lookup(prefix)
 	data := map[int]bool{}
@@ -288,15 +641,130 @@ func (p *parser) lookupForSlice(prefix string) (map[int]bool, error) {
 		if err != nil {
 			return nil, err
 		}
+		if i < 0 {
+			return nil, &strconv.NumError{Func: "Atoi", Num: k, Err: strconv.ErrRange}
+		}
+		//indices just define order in compact mode, so the position is limited instead
+		if p.opts.sparseArrayMode != SparseArrayCompact {
+			if err = p.limits.checkArrayIndex(i); err != nil {
+				return nil, err
+			}
+		}
 		data[i] = true
 	}
+
+	if p.opts.sparseArrayMode == SparseArrayCompact && len(data) > 0 {
+		if err := p.limits.checkArrayIndex(len(data) - 1); err != nil {
+			return nil, err
+		}
+	}
 	return data, nil
 }
 
 // get value by key from container variable which is map struct
-func (p *parser) get(key string) (string, bool) {
+// If key is repeated, the value is chosen by duplicate key policy
+func (p *parser) get(key string) (string, bool, error) {
 	v, ok := p.container[key]
-	return v, ok
+	if !ok || len(v) == 0 {
+		return "", false, nil
+	}
+	p.consumed[key] = true
+
+	switch p.opts.duplicateKeyPolicy {
+	case DuplicateKeyFirst:
+		return v[0], true, nil
+	case DuplicateKeyError:
+		if len(v) > 1 {
+			return "", false, ErrDuplicateKey{key: p.clientKey(key)}
+		}
+	case DuplicateKeyJoin:
+		return strings.Join(v, ","), true, nil
+	}
+	return v[len(v)-1], true, nil
+}
+
+// expand values of plain key into indexed keys according to array format
+// eg. ids=1,2 -> ids[0]=1&ids[1]=2 | ids=1&ids=2 -> ids[0]=1&ids[1]=2 | ids=1 -> ids[0]=1
+// expanded elements are counted as params, and checked by limits before being added
+func (p *parser) expandArrayValues(parentNode string, t *tag) error {
+	values, ok := p.container[parentNode]
+	if !ok || parentNode == "" {
+		return nil
+	}
+
+	delete(p.container, parentNode)
+	//elements replace the pairs of plain key
+	p.params -= len(values)
+
+	//elements are reported with the plain key sent by client
+	raw := p.clientKey(parentNode)
+	i := 0
+	add := func(v string) error {
+		p.params++
+		if p.limits.MaxParams > 0 && p.params > p.limits.MaxParams {
+			return ErrLimitExceeded{name: "MaxParams", limit: int64(p.limits.MaxParams)}
+		}
+		if err := p.countAppended(parentNode); err != nil {
+			return err
+		}
+
+		//skip indices already used by indexed keys
+		for p.has(p.genNextParentNode(parentNode, strconv.Itoa(i))) {
+			i++
+		}
+		if err := p.limits.checkArrayIndex(i); err != nil {
+			return err
+		}
+		key := p.genNextParentNode(parentNode, strconv.Itoa(i))
+		p.set(key, []string{v})
+		p.rawKeys[key] = raw
+		i++
+		return nil
+	}
+
+	//values of repeated key are elements in order, unless they are joined with separator
+	sep := t.getArrayFormat(p.opts.arrayFormat).separator()
+	for _, v := range values {
+		if sep == "" {
+			if err := add(v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		//split one by one, so that hostile value is rejected before all the elements are allocated
+		for v != "" {
+			n := strings.Index(v, sep)
+			if n < 0 {
+				if err := add(v); err != nil {
+					return err
+				}
+				break
+			}
+			if err := add(v[:n]); err != nil {
+				return err
+			}
+			//trailing separator means an empty element
+			if v = v[n+len(sep):]; v == "" {
+				if err := add(v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// check if key exists in container
+func (p *parser) has(key string) bool {
+	_, ok := p.container[key]
+	return ok
+}
+
+// RegisterTypeDecoder register self-defined decode function for specified type.
+// It just affects the specified type, instead of all types of the same reflect kind
+func (p *parser) RegisterTypeDecoder(typ reflect.Type, decode valueDecode) {
+	p.opts.converters.RegisterTypeDecoder(typ, decode)
 }
 
 // self-defined valueDecode function
@@ -306,13 +774,36 @@ func (p *parser) RegisterDecodeFunc(kind reflect.Kind, decode valueDecode) {
 
 // Unmarshal is supposed to decode string to go structure
 // It is thread safety
-func (p *parser) Unmarshal(data []byte, v interface{}) (err error) {
+func (p *parser) Unmarshal(data []byte, v interface{}) error {
+	return p.unmarshal(v, func() error {
+		return p.init(data)
+	})
+}
+
+// UnmarshalValues is supposed to decode url.Values to go structure, without string round-trip
+// It is thread safety
+func (p *parser) UnmarshalValues(values url.Values, v interface{}) error {
+	return p.unmarshal(v, func() error {
+		return p.initValues(values)
+	})
+}
+
+// fill container by load function, then parse go structure from it
+func (p *parser) unmarshal(v interface{}, load func() error) (err error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
 	//for duplicate use
-	p.container = map[string]string{}
+	p.container = map[string][]string{}
+	p.root = newKeyNode()
+	p.appended = map[string]*appendCounter{}
+	p.params = 0
+	p.consumed = map[string]bool{}
+	p.rawKeys = map[string]string{}
+	p.fieldPath = nil
+	p.decoded = 0
 	p.err = nil
+	p.errs = nil
 	p.resetQueryEncoder()
 
 	rv := reflect.ValueOf(v)
@@ -320,21 +811,60 @@ func (p *parser) Unmarshal(data []byte, v interface{}) (err error) {
 		return ErrInvalidUnmarshalError{}
 	}
 
-	err = p.init(data)
+	err = load()
 	if err != nil {
 		return
 	}
 
-	p.parse(rv, "")
+	p.parse(rv, "", nil)
+	if p.err == nil && p.opts.disallowUnknownKeys {
+		if err = p.checkUnknownKeys(); err != nil {
+			p.addError(err)
+		}
+	}
+	if p.err == nil && len(p.errs) > 0 {
+		p.err = DecodeErrors(p.errs)
+	}
 
 	//release resource
 	p.container = nil
+	p.root = nil
+	p.appended = nil
+	p.consumed = nil
+	p.rawKeys = nil
+	p.errs = nil
 	return p.err
 }
 
+// check if there are keys not consumed by any field
+func (p *parser) checkUnknownKeys() error {
+	//elements repacked from the same key sent by client are reported once
+	var keys []string
+	reported := map[string]bool{}
+	for k := range p.container {
+		if key := p.clientKey(k); !p.consumed[k] && !reported[key] {
+			reported[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sort.Strings(keys)
+	return ErrUnknownKeys{keys: keys}
+}
+
 // Unmarshal is supposed to decode string to go structure
 // It is thread safety
 func Unmarshal(data []byte, v interface{}) error {
 	p := NewParser()
 	return p.Unmarshal(data, v)
 }
+
+// UnmarshalValues is supposed to decode url.Values to go structure
+// It is thread safety
+func UnmarshalValues(values url.Values, v interface{}) error {
+	p := NewParser()
+	return p.UnmarshalValues(values, v)
+}
@@ -2,10 +2,12 @@ package urlquery
 
 import (
 	"errors"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 type testParseChild struct {
@@ -233,7 +235,7 @@ func TestParser_Unmarshal_UnmatchedDataFormat(t *testing.T) {
 	if err == nil {
 		t.Error("error should not be ignored")
 	}
-	if _, ok := err.(ErrTranslated); !ok {
+	if e := (ErrTranslated{}); !errors.As(err, &e) {
 		t.Errorf("error type is unexpected. %v", err)
 	}
 }
@@ -254,7 +256,7 @@ func TestParser_Unmarshal_UnhandledType(t *testing.T) {
 
 type TestUnhandled struct {
 	Id     int
-	Params map[string]testFormat
+	Params map[string]complex64
 }
 
 func TestParser_Unmarshal_UnhandledType2(t *testing.T) {
@@ -310,7 +312,7 @@ func TestParser_Unmarshal_MapKey_DecodeError(t *testing.T) {
 	var data = "Id=1&b=2"
 	v := &map[string]int{}
 	err := parser.Unmarshal([]byte(data), v)
-	if _, ok := err.(ErrUnhandledType); !ok {
+	if e := (ErrUnhandledType{}); !errors.As(err, &e) {
 		t.Error("unmatched error")
 	}
 }
@@ -321,7 +323,7 @@ func TestParser_Unmarshal_MapValue_DecodeError(t *testing.T) {
 	var data = "Id=1&b=2"
 	v := &map[string]int{}
 	err := parser.Unmarshal([]byte(data), v)
-	if _, ok := err.(ErrUnhandledType); !ok {
+	if e := (ErrUnhandledType{}); !errors.As(err, &e) {
 		t.Error("unmatched error")
 	}
 }
@@ -345,7 +347,7 @@ func TestParser_lookupForSlice(t *testing.T) {
 		Tags []int
 	}{}
 	err := Unmarshal([]byte(data), v)
-	if _, ok := err.(*strconv.NumError); !ok {
+	if e := (*strconv.NumError)(nil); !errors.As(err, &e) {
 		t.Error("dont failed for wrong slice data")
 	}
 }
@@ -365,7 +367,7 @@ func TestParser_SliceEmpty(t *testing.T) {
 func TestParser_decode_UnhandledType(t *testing.T) {
 	parser := NewParser()
 	parser.RegisterDecodeFunc(reflect.String, nil)
-	_, err := parser.decode(reflect.TypeOf(""), "s")
+	_, err := parser.decode(reflect.TypeOf(""), "s", nil)
 	if _, ok := err.(ErrUnhandledType); !ok {
 		t.Error("unmatched error")
 	}
@@ -375,14 +377,634 @@ func TestParser_parseForMap_CanSet(t *testing.T) {
 	var x = 3.4
 	v := reflect.ValueOf(x)
 	parser := NewParser()
-	parser.parseForMap(v, "")
+	parser.parseForMap(v, "", nil)
 }
 
 func TestParser_parseForSlice_CanSet(t *testing.T) {
 	var x = 3.4
 	v := reflect.ValueOf(x)
 	parser := NewParser()
-	parser.parseForSlice(v, "")
+	parser.parseForSlice(v, "", nil)
+}
+
+func TestParser_Unmarshal_TextUnmarshaler(t *testing.T) {
+	data := "level=high&point=1:2&pointPtr=3:4&levels[]=low&levels[]=high"
+	data = encodeSquareBracket(data)
+	v := &testTextData{}
+	err := Unmarshal([]byte(data), v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v.Level != 2 || v.Point.X != 1 || v.Point.Y != 2 {
+		t.Error("failed to Unmarshal TextUnmarshaler")
+	}
+	if v.PointPtr == nil || v.PointPtr.X != 3 || v.PointPtr.Y != 4 {
+		t.Error("failed to Unmarshal pointer of TextUnmarshaler")
+	}
+	if len(v.Levels) != 2 || v.Levels[0] != 1 || v.Levels[1] != 2 {
+		t.Error("failed to Unmarshal slice of TextUnmarshaler")
+	}
+}
+
+func TestParser_Unmarshal_TextUnmarshalerError(t *testing.T) {
+	v := &testTextData{}
+	err := Unmarshal([]byte("level=middle"), v)
+	if e := (ErrTranslated{}); !errors.As(err, &e) {
+		t.Error("unmatched error")
+	}
+}
+
+func TestParser_Unmarshal_Duration(t *testing.T) {
+	data := "timeout=1500000000&delays[a]=2s&delays[b]=10"
+	data = encodeSquareBracket(data)
+	v := &testTimeData{}
+	err := Unmarshal([]byte(data), v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v.Timeout != 1500*time.Millisecond || v.Delays["a"] != 2*time.Second || v.Delays["b"] != 10 {
+		t.Error("failed to Unmarshal duration")
+	}
+}
+
+func TestParser_Unmarshal_TimeError(t *testing.T) {
+	v := &testTimeData{}
+	err := Unmarshal([]byte("since=2021-13-01"), v)
+	if e := (ErrTranslated{}); !errors.As(err, &e) {
+		t.Error("unmatched error")
+	}
+
+	err = Unmarshal([]byte("unix=abc"), v)
+	if e := (ErrTranslated{}); !errors.As(err, &e) {
+		t.Error("unmatched error")
+	}
+
+	err = Unmarshal([]byte("timeout=1x"), v)
+	if e := (ErrTranslated{}); !errors.As(err, &e) {
+		t.Error("unmatched error")
+	}
+}
+
+type testRepeatData struct {
+	Tags   []string `query:"tag"`
+	Ids    [2]int   `query:"id"`
+	Ptr    *[]int   `query:"ptr"`
+	Single []int    `query:"single"`
+	Child  struct {
+		Names []string `query:"name"`
+	} `query:"child"`
+	Role string `query:"role"`
+}
+
+func TestParser_Unmarshal_RepeatedKey(t *testing.T) {
+	data := "tag=a&tag=b&id=1&id=2&ptr=3&ptr=4&single=5&child[name]=x&child[name]=y&role=user&role=admin"
+	data = encodeSquareBracket(data)
+	v := &testRepeatData{}
+	err := Unmarshal([]byte(data), v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !reflect.DeepEqual(v.Tags, []string{"a", "b"}) || v.Ids != [2]int{1, 2} || v.Ptr == nil ||
+		!reflect.DeepEqual(*v.Ptr, []int{3, 4}) || !reflect.DeepEqual(v.Single, []int{5}) {
+		t.Error("failed to Unmarshal repeated key", v)
+	}
+	if !reflect.DeepEqual(v.Child.Names, []string{"x", "y"}) || v.Role != "admin" {
+		t.Error("failed to Unmarshal repeated key", v)
+	}
+}
+
+func TestParser_Unmarshal_DuplicateKeyPolicy(t *testing.T) {
+	data := []byte("tag=a&tag=b&role=user&role=admin")
+
+	v := &testRepeatData{}
+	err := NewParser(WithDuplicateKeyPolicy(DuplicateKeyFirst)).Unmarshal(data, v)
+	if err != nil || v.Role != "user" || len(v.Tags) != 2 {
+		t.Error("failed to Unmarshal with DuplicateKeyFirst", err, v)
+	}
+
+	v = &testRepeatData{}
+	err = NewParser(WithDuplicateKeyPolicy(DuplicateKeyError)).Unmarshal(data, v)
+	if e := (ErrDuplicateKey{}); !errors.As(err, &e) || e.key != "role" {
+		t.Error("unmatched error", err)
+	}
+
+	m := map[string]string{}
+	err = NewParser(WithDuplicateKeyPolicy(DuplicateKeyError)).Unmarshal(data, &m)
+	if e := (ErrDuplicateKey{}); !errors.As(err, &e) {
+		t.Error("unmatched error", err)
+	}
+
+	v = &testRepeatData{}
+	err = NewParser(WithDuplicateKeyPolicy(DuplicateKeyJoin)).Unmarshal(data, v)
+	if err != nil || v.Role != "user,admin" || len(v.Tags) != 2 {
+		t.Error("failed to Unmarshal with DuplicateKeyJoin", err, v)
+	}
+
+	v = &testRepeatData{}
+	err = NewParser(WithDuplicateKeyPolicy(DuplicateKeyError)).Unmarshal([]byte(encodeSquareBracket("id[0]=1&id[0]=2")), v)
+	if e := (ErrDuplicateKey{}); !errors.As(err, &e) || e.Key() != "id[0]" {
+		t.Error("unmatched error", err)
+	}
+}
+
+func TestParser_Unmarshal_DisallowUnknownKeys(t *testing.T) {
+	data := "desc=a&Long=1&pagesize=50&Height=3&child[x]=1"
+	data = encodeSquareBracket(data)
+	v := &testParseChild{}
+	err := NewParser(WithDisallowUnknownKeys()).Unmarshal([]byte(data), v)
+	e, ok := err.(ErrUnknownKeys)
+	if !ok || !reflect.DeepEqual(e.Keys(), []string{"Height", "child[x]", "pagesize"}) {
+		t.Error("unmatched error", err)
+	}
+	if v.Description != "a" || v.Long != 1 {
+		t.Error("known keys should be parsed")
+	}
+
+	m := &testMarshalerData{}
+	data = encodeSquareBracket("id=1&price[amount]=1&price[currency]=USD&tag[]=a&tag[]=b&sort=a")
+	err = NewParser(WithDisallowUnknownKeys()).Unmarshal([]byte(data), m)
+	if e, ok := err.(ErrUnknownKeys); !ok || !reflect.DeepEqual(e.Keys(), []string{"tag[]"}) {
+		t.Error("unmatched error", err)
+	}
+
+	r := &testRepeatData{}
+	err = NewParser(WithDisallowUnknownKeys()).Unmarshal([]byte("tag=a&tag=b&id=1&role=a"), r)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParser_UnmarshalValues(t *testing.T) {
+	values := url.Values{
+		"tag[]":      {"a b", "c&d"},
+		"id":         {"1", "2"},
+		"child.name": {"x", "y"},
+		"role":       {"user", "admin"},
+		"unknown":    {"u"},
+	}
+	v := testRepeatData{}
+	err := NewParser(WithKeyStyle(KeyStyleDots)).UnmarshalValues(values, &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !reflect.DeepEqual(v.Tags, []string{"a b", "c&d"}) || v.Ids != [2]int{1, 2} ||
+		!reflect.DeepEqual(v.Child.Names, []string{"x", "y"}) || v.Role != "admin" {
+		t.Error("failed to UnmarshalValues", v)
+	}
+
+	err = NewParser(WithDisallowUnknownKeys()).UnmarshalValues(values, &v)
+	if e := (ErrUnknownKeys{}); !errors.As(err, &e) || !reflect.DeepEqual(e.Keys(), []string{"child.name", "unknown"}) {
+		t.Error("unmatched error", err)
+	}
+
+	err = UnmarshalValues(values, v)
+	if _, ok := err.(ErrInvalidUnmarshalError); !ok {
+		t.Error("unmatched error", err)
+	}
+}
+
+func TestParser_UnmarshalValues_RoundTrip(t *testing.T) {
+	data := "Id=1&name=test&child[desc]=c1&child[Long]=10&childPtr[Long]=2&childPtr[Description]=b" +
+		"&children[0][desc]=d1&children[1][Long]=12&desc=rtt&Params[120]=1&Params[121]=2&status=1&UintPtr=300"
+	v1 := testParseInfo{}
+	err := Unmarshal([]byte(encodeSquareBracket(data)), &v1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	values, err := MarshalValues(v1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	v2 := testParseInfo{}
+	err = UnmarshalValues(values, &v2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(v1, v2) {
+		t.Error("unmatched result of UnmarshalValues and Unmarshal", v1, v2)
+	}
+}
+
+func TestParser_Unmarshal_AppendedKey(t *testing.T) {
+	data := encodeSquareBracket("ids[]=a&ids[1]=b&ids[]=c&ids[]=d&ids[3]=e&names[]=x")
+	v := map[string][]string{}
+	err := Unmarshal([]byte(data), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	//ids[1] is taken by indexed key, and ids[3] is repeated whose last value is used
+	if !reflect.DeepEqual(v["ids"], []string{"a", "b", "c", "e"}) || !reflect.DeepEqual(v["names"], []string{"x"}) {
+		t.Error("failed to Unmarshal appended key", v)
+	}
+
+	n := 100000
+	large := strings.Repeat(encodeSquareBracket("ids[]=1&"), n)
+	v = map[string][]string{}
+	err = NewParser(WithLimits(Limits{})).Unmarshal([]byte(large), &v)
+	if err != nil || len(v["ids"]) != n {
+		t.Error("failed to Unmarshal large appended key", len(v["ids"]), err)
+	}
+}
+
+func TestParser_Unmarshal_MaxArrayLength(t *testing.T) {
+	data := encodeSquareBracket("ids[]=1&ids[]=2&names[]=4&ids[]=3")
+	v := map[string][]int{}
+	err := NewParser(WithMaxArrayLength(2)).Unmarshal([]byte(data), &v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxArrayLength" || e.Limit() != 2 {
+		t.Error("unmatched error", err)
+	}
+
+	err = NewParser(WithMaxArrayLength(3)).Unmarshal([]byte(data), &v)
+	if err != nil || !reflect.DeepEqual(v["ids"], []int{1, 2, 3}) {
+		t.Error("array within limit should be decoded", v, err)
+	}
+
+	values := url.Values{"ids[]": {"1", "2", "3"}}
+	err = NewParser(WithMaxArrayLength(2)).UnmarshalValues(values, &v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) {
+		t.Error("unmatched error", err)
+	}
+
+	//indexed keys are not counted
+	data = encodeSquareBracket("ids[0]=0&ids[1]=1&ids[2]=2&ids[3]=3&ids[]=4&ids[]=5")
+	err = NewParser(WithMaxArrayLength(2)).Unmarshal([]byte(data), &v)
+	if err != nil || !reflect.DeepEqual(v["ids"], []int{0, 1, 2, 3, 4, 5}) {
+		t.Error("indexed keys should not be counted", v, err)
+	}
+
+	//repeated keys and separated values are counted
+	for _, data := range []string{"ids=1&ids=2&ids=3&ids=4", "ids=1,2,3,4", "ids=1,2&ids[]=3&ids[]=4"} {
+		s := struct {
+			Ids []int `query:"ids,comma"`
+		}{}
+		err = NewParser(WithMaxArrayLength(3)).Unmarshal([]byte(encodeSquareBracket(data)), &s)
+		if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxArrayLength" {
+			t.Error("unmatched error", data, err)
+		}
+	}
+}
+
+type testSparseData struct {
+	Ids      []int             `query:"ids"`
+	Children []testParseChild  `query:"children"`
+	Sparse   map[int]string    `query:"sparse"`
+	Nested   map[int]testRange `query:"nested"`
+}
+
+func TestParser_Unmarshal_SparseArray(t *testing.T) {
+	data := encodeSquareBracket("ids[3]=3&ids[1]=1&children[5][desc]=c5&children[2][desc]=c2")
+
+	v := testSparseData{}
+	err := Unmarshal([]byte(data), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(v.Ids, []int{0, 1, 0, 3}) || len(v.Children) != 6 || v.Children[5].Description != "c5" {
+		t.Error("failed to Unmarshal sparse array by default", v)
+	}
+
+	v = testSparseData{}
+	err = NewParser(WithSparseArrayMode(SparseArrayCompact)).Unmarshal([]byte(data), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(v.Ids, []int{1, 3}) ||
+		!reflect.DeepEqual(v.Children, []testParseChild{{Description: "c2"}, {Description: "c5"}}) {
+		t.Error("failed to Unmarshal sparse array in compact mode", v)
+	}
+
+	//field path is position of element, while key is the original one
+	err = NewParser(WithSparseArrayMode(SparseArrayCompact)).Unmarshal([]byte(encodeSquareBracket("ids[7]=x")), &v)
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Key != "ids[7]" || de.Field != "Ids[0]" {
+		t.Error("unmatched error", err)
+	}
+
+	v = testSparseData{}
+	err = NewParser(WithSparseArrayMode(SparseArrayReject)).Unmarshal([]byte(data), &v)
+	if e := (ErrSparseArray{}); !errors.As(err, &e) || e.Key() != "ids" || e.Index() != 0 || !errors.As(err, &de) || de.Field != "Ids" {
+		t.Error("unmatched error", err)
+	}
+
+	err = NewParser(WithSparseArrayMode(SparseArrayReject)).Unmarshal([]byte(encodeSquareBracket("ids[1]=1&ids[0]=0&ids[]=2")), &v)
+	if err != nil || !reflect.DeepEqual(v.Ids, []int{0, 1, 2}) {
+		t.Error("contiguous array should be decoded", v, err)
+	}
+}
+
+func TestParser_Unmarshal_SparseArrayLimit(t *testing.T) {
+	v := testSparseData{}
+	err := NewParser(WithSparseArrayMode(SparseArrayCompact)).Unmarshal([]byte(encodeSquareBracket("ids[20000]=1&ids[5]=0")), &v)
+	if err != nil || !reflect.DeepEqual(v.Ids, []int{0, 1}) {
+		t.Error("large index should be accepted in compact mode", v, err)
+	}
+
+	err = Unmarshal([]byte(encodeSquareBracket("ids[20000]=1")), &v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxArrayIndex" {
+		t.Error("unmatched error", err)
+	}
+
+	parser := NewParser(WithSparseArrayMode(SparseArrayCompact), WithLimits(Limits{MaxArrayIndex: 1}))
+	err = parser.Unmarshal([]byte(encodeSquareBracket("ids[100]=1&ids[200]=2&ids[300]=3")), &v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxArrayIndex" {
+		t.Error("count of elements should be limited in compact mode", err)
+	}
+}
+
+func TestParser_Unmarshal_SparseMap(t *testing.T) {
+	data := encodeSquareBracket("sparse[99999999]=a&sparse[3]=b&nested[5000][Min]=1")
+	v := testSparseData{}
+	err := Unmarshal([]byte(data), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !reflect.DeepEqual(v.Sparse, map[int]string{99999999: "a", 3: "b"}) ||
+		!reflect.DeepEqual(v.Nested, map[int]testRange{5000: {Min: 1}}) {
+		t.Error("failed to Unmarshal sparse map", v)
+	}
+}
+
+func TestParser_Unmarshal_SliceLen(t *testing.T) {
+	v := struct {
+		Ids []int `query:"ids"`
+	}{Ids: make([]int, 0, 5)}
+	err := Unmarshal([]byte(encodeSquareBracket("ids[2]=2")), &v)
+	if err != nil || !reflect.DeepEqual(v.Ids, []int{0, 0, 2}) {
+		t.Error("failed to Unmarshal slice with enough cap", v, err)
+	}
+}
+
+type testDecodeErrorData struct {
+	Filter struct {
+		Items []struct {
+			Qty int `query:"qty"`
+		} `query:"items"`
+	} `query:"filter"`
+	Params map[string]int `query:"params"`
+	Levels [2]testTextLevel
+}
+
+func TestParser_Unmarshal_DecodeError(t *testing.T) {
+	cases := map[string]DecodeError{
+		"filter[items][3][qty]=abc": {Key: "filter[items][3][qty]", Field: "Filter.Items[3].Qty", Type: reflect.TypeOf(0), Value: "abc"},
+		"params[a]=x":               {Key: "params[a]", Field: "Params[a]", Type: reflect.TypeOf(0), Value: "x"},
+		"Levels[1]=middle":          {Key: "Levels[1]", Field: "Levels[1]", Type: reflect.TypeOf(testTextLevel(0)), Value: "middle"},
+		"filter[items][a][qty]=1":   {Key: "filter[items]", Field: "Filter.Items", Type: reflect.TypeOf(testDecodeErrorData{}.Filter.Items)},
+	}
+	for data, expect := range cases {
+		v := &testDecodeErrorData{}
+		err := Unmarshal([]byte(encodeSquareBracket(data)), v)
+
+		var e *DecodeError
+		if !errors.As(err, &e) {
+			t.Error("unmatched error", err)
+			continue
+		}
+		if e.Key != expect.Key || e.Field != expect.Field || e.Type != expect.Type || e.Value != expect.Value || e.Err == nil {
+			t.Error("failed to make DecodeError", e)
+		}
+	}
+}
+
+func TestParser_Unmarshal_DecodeError_DotKey(t *testing.T) {
+	cases := map[string]string{
+		"filter.items.3.qty=abc":    "filter.items.3.qty",
+		"filter[items].3[qty]=abc":  "filter[items].3[qty]",
+		"filter[items][3][qty]=abc": "filter[items][3][qty]",
+		"filter.items.a.qty=1":      "filter.items",
+		"params.a=1&params.a=2":     "params.a",
+	}
+	for data, key := range cases {
+		v := &testDecodeErrorData{}
+		parser := NewParser(WithKeyStyle(KeyStyleDots), WithDuplicateKeyPolicy(DuplicateKeyError))
+		err := parser.Unmarshal([]byte(encodeSquareBracket(data)), v)
+
+		var e *DecodeError
+		if !errors.As(err, &e) || e.Key != key {
+			t.Error("key should be reported as sent by client", data, err)
+		}
+	}
+
+	err := NewParser(WithKeyStyle(KeyStyleDots), WithDisallowUnknownKeys()).Unmarshal([]byte("unknown.a=1"), &testDecodeErrorData{})
+	if e := (ErrUnknownKeys{}); !errors.As(err, &e) || !reflect.DeepEqual(e.Keys(), []string{"unknown.a"}) {
+		t.Error("unmatched error", err)
+	}
+}
+
+func TestParser_Unmarshal_DecodeError_RepackedKey(t *testing.T) {
+	type testIds struct {
+		Ids []int `query:"ids"`
+		A   struct {
+			Ids []int `query:"ids"`
+		} `query:"a"`
+	}
+	cases := []struct {
+		data   string
+		format ArrayFormat
+		style  KeyStyle
+		key    string
+	}{
+		{"ids=1,x", ArrayFormatComma, KeyStyleBrackets, "ids"},
+		{"ids=1&ids=x", ArrayFormatRepeat, KeyStyleBrackets, "ids"},
+		{"ids[]=1&ids[]=x", ArrayFormatBrackets, KeyStyleBrackets, "ids[]"},
+		{"a[ids][]=1&a[ids][]=x", ArrayFormatBrackets, KeyStyleBrackets, "a[ids][]"},
+		{"ids=1,x", ArrayFormatComma, KeyStyleDots, "ids"},
+		{"ids=1&ids=x", ArrayFormatRepeat, KeyStyleDots, "ids"},
+		{"ids[]=1&ids[]=x", ArrayFormatBrackets, KeyStyleDots, "ids[]"},
+		{"a.ids=1,x", ArrayFormatComma, KeyStyleDots, "a.ids"},
+	}
+	for _, c := range cases {
+		v := &testIds{}
+		err := NewParser(WithArrayFormat(c.format), WithKeyStyle(c.style)).Unmarshal([]byte(encodeSquareBracket(c.data)), v)
+
+		var e *DecodeError
+		if !errors.As(err, &e) || e.Key != c.key {
+			t.Error("repacked key should be reported as sent by client", c.data, err)
+		}
+	}
+}
+
+func TestParser_Unmarshal_CollectErrors(t *testing.T) {
+	data := "Id=x&name=test&Uint=-1&Float32=1.5&tags[]=a&tags[]=2&pagesize=1"
+	data = encodeSquareBracket(data)
+	v := &testParseInfo{}
+	err := NewParser(WithCollectErrors(), WithDisallowUnknownKeys()).Unmarshal([]byte(data), v)
+
+	errs, ok := err.(DecodeErrors)
+	if !ok || len(errs) != 4 {
+		t.Error("unmatched error", err)
+		return
+	}
+
+	keys := make([]string, 0, len(errs))
+	for _, e := range errs {
+		var de *DecodeError
+		if errors.As(e, &de) {
+			keys = append(keys, de.Key)
+		}
+	}
+	if !reflect.DeepEqual(keys, []string{"Id", "tags[]", "Uint"}) {
+		t.Error("failed to collect errors", keys)
+	}
+	if e := (ErrUnknownKeys{}); !errors.As(err, &e) || e.Keys()[0] != "pagesize" {
+		t.Error("failed to collect unknown keys", err)
+	}
+	if e := (ErrTranslated{}); !errors.As(err, &e) {
+		t.Error("failed to unwrap DecodeErrors")
+	}
+
+	if v.Name != "test" || v.Float32 != 1.5 || !reflect.DeepEqual(v.Tags, []int16{0, 2}) {
+		t.Error("failed to fill valid values", v)
+	}
+
+	err = NewParser(WithCollectErrors()).Unmarshal([]byte("name=test"), v)
+	if err != nil {
+		t.Error("nil error is expected", err)
+	}
+}
+
+type testNestedMapData struct {
+	Filters map[string][]string         `query:"filters"`
+	Ranges  map[string]testRange        `query:"ranges"`
+	Nested  map[string]map[string]int   `query:"nested"`
+	Ptrs    map[int]*testParseChild     `query:"ptrs"`
+	Levels  map[string][2]testTextLevel `query:"levels"`
+}
+
+func TestParser_Unmarshal_NestedMap(t *testing.T) {
+	data := testNestedMapData{
+		Filters: map[string][]string{"status": {"open", "closed"}, "owner": {"me"}},
+		Ranges:  map[string]testRange{"price": {Min: 1, Max: 10}},
+		Nested:  map[string]map[string]int{"a": {"x": 1, "y": 2}},
+		Ptrs:    map[int]*testParseChild{3: {Description: "d3", Long: 3}},
+		Levels:  map[string][2]testTextLevel{"l": {1, 2}},
+	}
+	bytes, err := Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	v := testNestedMapData{}
+	err = Unmarshal(bytes, &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(v, data) {
+		t.Error("failed to Unmarshal nested map", v)
+	}
+}
+
+func TestParser_Unmarshal_NestedMap_Error(t *testing.T) {
+	data := encodeSquareBracket("ranges[price][Min]=x&filters[a][]=1")
+	v := testNestedMapData{}
+	err := NewParser(WithCollectErrors()).Unmarshal([]byte(data), &v)
+
+	var e *DecodeError
+	if !errors.As(err, &e) || e.Key != "ranges[price][Min]" || e.Field != "Ranges[price].Min" {
+		t.Error("unmatched error", err)
+	}
+	if len(v.Filters["a"]) != 1 {
+		t.Error("valid entry should be parsed", v)
+	}
+	if _, ok := v.Ranges["price"]; ok {
+		t.Error("invalid entry should not be set", v)
+	}
+}
+
+type testGenericData struct {
+	Name  string                 `query:"name"`
+	Extra map[string]interface{} `query:"extra"`
+	Any   interface{}            `query:"any"`
+}
+
+func TestParser_Unmarshal_Generic(t *testing.T) {
+	data := encodeSquareBracket("a=1&b[x]=2&b[y][]=3&b[y][]=4&c[2]=z&c[0]=x&d=5&d=6&e[0][k]=v&tags2=t")
+	var v interface{}
+	err := Unmarshal([]byte(data), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := map[string]interface{}{
+		"a":     "1",
+		"b":     map[string]interface{}{"x": "2", "y": []interface{}{"3", "4"}},
+		"c":     []interface{}{"x", "z"},
+		"d":     []interface{}{"5", "6"},
+		"e":     []interface{}{map[string]interface{}{"k": "v"}},
+		"tags2": "t",
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Error("failed to Unmarshal generic value", v)
+	}
+
+	m := map[string]interface{}{}
+	err = Unmarshal([]byte(data), &m)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Error("failed to Unmarshal generic map", m)
+	}
+}
+
+func TestParser_Unmarshal_GenericField(t *testing.T) {
+	data := encodeSquareBracket("name=n&extra[page]=2&extra[sort][0]=id&extra[01]=x&any[k]=v")
+	v := testGenericData{}
+	err := NewParser(WithDisallowUnknownKeys()).Unmarshal([]byte(data), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := testGenericData{
+		Name:  "n",
+		Extra: map[string]interface{}{"page": "2", "sort": []interface{}{"id"}, "01": "x"},
+		Any:   map[string]interface{}{"k": "v"},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Error("failed to Unmarshal generic field", v)
+	}
+
+	//interface holding pointer is parsed into the pointed value
+	child := &testParseChild{}
+	v2 := testGenericData{Any: child}
+	err = Unmarshal([]byte(encodeSquareBracket("any[desc]=d&any[Long]=3")), &v2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if v2.Any != child || child.Description != "d" || child.Long != 3 {
+		t.Error("failed to Unmarshal into pointer held by interface", child)
+	}
+
+	//absent key leaves interface untouched
+	v3 := testGenericData{}
+	_ = Unmarshal([]byte("name=n"), &v3)
+	if v3.Any != nil {
+		t.Error("nil interface is expected", v3.Any)
+	}
 }
 
 //mock multi-layer nested structure,
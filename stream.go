@@ -0,0 +1,131 @@
+package urlquery
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+)
+
+// A streamEncoder writes URL Query string of go structure into io.Writer incrementally,
+// without holding the full output in memory
+type streamEncoder struct {
+	target  io.Writer
+	writer  *bufio.Writer
+	encoder *encoder
+}
+
+// NewStreamEncoder return new stream encoder object writing into w
+// do some option initialization
+func NewStreamEncoder(w io.Writer, opts ...Option) *streamEncoder {
+	return &streamEncoder{
+		target:  w,
+		writer:  bufio.NewWriter(w),
+		encoder: NewEncoder(opts...),
+	}
+}
+
+// Encode write key-value pairs of go structure into writer, and flush it.
+// Pairs of successive calls are joined with & character, so that they form a single URL Query string.
+// If error happens, buffered pairs of this call are discarded, so that they never show up in output of later calls.
+// Only pairs of a large structure already flushed before the error remain in writer.
+// it is thread safety
+func (s *streamEncoder) Encode(data interface{}) error {
+	s.encoder.mutex.Lock()
+	defer s.encoder.mutex.Unlock()
+
+	written := s.encoder.written
+	err := s.encoder.encodeTo(s.writer, data)
+	if err != nil {
+		s.writer.Reset(s.target)
+		s.encoder.written = written
+		return err
+	}
+	return s.writer.Flush()
+}
+
+// RegisterTypeEncoder register self-defined encode function for specified type.
+func (s *streamEncoder) RegisterTypeEncoder(typ reflect.Type, encode valueEncode) {
+	s.encoder.RegisterTypeEncoder(typ, encode)
+}
+
+// register self-defined encode function for any reflect kind
+func (s *streamEncoder) RegisterEncodeFunc(kind reflect.Kind, encode valueEncode) {
+	s.encoder.RegisterEncodeFunc(kind, encode)
+}
+
+// A decoder reads URL Query string from io.Reader and decodes it to go structure.
+// Key-value pairs are tokenized incrementally, with limits on total bytes and pair count
+type decoder struct {
+	reader io.Reader
+	parser *parser
+}
+
+// NewDecoder return new decoder object reading from r
+// do some option initialization
+func NewDecoder(r io.Reader, opts ...Option) *decoder {
+	return &decoder{
+		reader: r,
+		parser: NewParser(opts...),
+	}
+}
+
+// Decode read URL Query string until EOF, and decode it to go structure
+// it is thread safety
+func (d *decoder) Decode(v interface{}) error {
+	return d.parser.unmarshal(v, d.load)
+}
+
+// read & separated key-value pairs one by one into container of parser
+func (d *decoder) load() error {
+	p := d.parser
+	name, limit := "MaxBytes", p.opts.maxBytes
+	if limit <= 0 {
+		name, limit = "MaxTotalBytes", p.limits.MaxTotalBytes
+	}
+
+	//read one more byte to detect input exceeding the limit
+	reader := d.reader
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit+1)
+	}
+	r := bufio.NewReader(reader)
+	var total int64
+	pairs := 0
+	for {
+		token, err := r.ReadBytes(SymbolAnd[0])
+		total += int64(len(token))
+		if limit > 0 && total > limit {
+			return ErrLimitExceeded{name: name, limit: limit}
+		}
+
+		token = bytes.TrimSuffix(token, []byte(SymbolAnd))
+		if len(token) > 0 {
+			pairs++
+			if p.opts.maxPairs > 0 && pairs > p.opts.maxPairs {
+				return ErrLimitExceeded{name: "MaxPairs", limit: int64(p.opts.maxPairs)}
+			}
+
+			if e := p.split(token, p.add); e != nil {
+				return e
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// RegisterTypeDecoder register self-defined decode function for specified type.
+func (d *decoder) RegisterTypeDecoder(typ reflect.Type, decode valueDecode) {
+	d.parser.RegisterTypeDecoder(typ, decode)
+}
+
+// self-defined valueDecode function
+func (d *decoder) RegisterDecodeFunc(kind reflect.Kind, decode valueDecode) {
+	d.parser.RegisterDecodeFunc(kind, decode)
+}
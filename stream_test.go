@@ -0,0 +1,257 @@
+package urlquery
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type countWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+type errorWriter struct{}
+
+func (w errorWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write")
+}
+
+func TestStreamEncoder_Encode(t *testing.T) {
+	data := getMockData3()
+	expected, err := Marshal(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	err = NewStreamEncoder(buf).Encode(data)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if buf.String() != string(expected) {
+		t.Error("unmatched result of stream encoder and Marshal", buf.String())
+	}
+}
+
+func TestStreamEncoder_Encode_Successive(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := NewStreamEncoder(buf, WithArrayFormat(ArrayFormatRepeat))
+	for _, ids := range [][]int{{1, 2}, {}, {3}} {
+		err := e.Encode(map[string][]int{"id": ids})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	if buf.String() != "id=1&id=2&id=3" {
+		t.Error("failed to join successive calls", buf.String())
+	}
+}
+
+func TestStreamEncoder_Encode_Incremental(t *testing.T) {
+	ids := make([]int, 2000)
+	for i := range ids {
+		ids[i] = i
+	}
+
+	w := &countWriter{}
+	err := NewStreamEncoder(w).Encode(map[string][]int{"ids": ids})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if w.writes < 2 {
+		t.Error("output should be written incrementally", w.writes)
+	}
+
+	v := map[string][]int{}
+	err = Unmarshal(w.Bytes(), &v)
+	if err != nil || !reflect.DeepEqual(v["ids"], ids) {
+		t.Error("failed to decode streamed output", err)
+	}
+}
+
+func TestStreamEncoder_Encode_Error(t *testing.T) {
+	err := NewStreamEncoder(errorWriter{}).Encode(map[string]int{"a": 1})
+	if err == nil || err.Error() != "write" {
+		t.Error("unmatched error", err)
+	}
+
+	err = NewStreamEncoder(new(bytes.Buffer)).Encode(map[string]complex64{"d": complex(1, 2)})
+	if _, ok := err.(ErrUnhandledType); !ok {
+		t.Error("unmatched error", err)
+	}
+}
+
+func TestStreamEncoder_Encode_ErrorThenSuccess(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := NewStreamEncoder(buf)
+	err := e.Encode(map[string]interface{}{"a": 1, "b": complex(1, 1)})
+	if _, ok := err.(ErrUnhandledType); !ok {
+		t.Error("unmatched error", err)
+	}
+	if buf.Len() != 0 {
+		t.Error("partial output of failed call should be discarded", buf.String())
+	}
+
+	err = e.Encode(map[string]int{"z": 1})
+	if err != nil || buf.String() != "z=1" {
+		t.Error("failed call should not affect later calls", buf.String(), err)
+	}
+
+	err = e.Encode(map[string]interface{}{"c": complex(1, 1)})
+	if err == nil {
+		t.Error("error should be returned")
+	}
+	err = e.Encode(map[string]int{"y": 2})
+	if err != nil || buf.String() != "z=1&y=2" {
+		t.Error("failed call should not affect later calls", buf.String(), err)
+	}
+}
+
+func TestStreamEncoder_RegisterEncodeFunc(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := NewStreamEncoder(buf)
+	e.RegisterEncodeFunc(reflect.String, func(rv reflect.Value) string {
+		return "x"
+	})
+	e.RegisterTypeEncoder(reflect.TypeOf(testCurrency("")), func(rv reflect.Value) string {
+		return "c"
+	})
+
+	err := e.Encode(struct {
+		A string
+		C testCurrency
+	}{"a", "usd"})
+	if err != nil || buf.String() != "A=x&C=c" {
+		t.Error("failed to register encode func", buf.String(), err)
+	}
+}
+
+type errorReader struct{}
+
+func (r errorReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read")
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	data := "Id=1&name=test&child[desc]=c1&child[Long]=10&&children[0][desc]=d1&children[1][Long]=12" +
+		"&desc=rtt&Params[120]=1&Params[121]=2&status=1&UintPtr=300&invalid"
+	data = encodeSquareBracket(data)
+
+	expected := testParseInfo{}
+	err := Unmarshal([]byte(data), &expected)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	v := testParseInfo{}
+	err = NewDecoder(strings.NewReader(data)).Decode(&v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Error("unmatched result of decoder and Unmarshal", v, expected)
+	}
+}
+
+func TestDecoder_Decode_Stream(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := NewStreamEncoder(buf)
+	ids := make([]int, 2000)
+	for i := range ids {
+		ids[i] = i
+	}
+	_ = e.Encode(map[string][]int{"ids": ids})
+
+	v := map[string][]int{}
+	err := NewDecoder(bufio.NewReaderSize(buf, 16)).Decode(&v)
+	if err != nil || !reflect.DeepEqual(v["ids"], ids) {
+		t.Error("failed to decode stream", err)
+	}
+}
+
+func TestDecoder_Decode_Limit(t *testing.T) {
+	data := "a=1&b=2&c=3"
+	v := map[string]int{}
+	err := NewDecoder(strings.NewReader(data), WithMaxBytes(10)).Decode(&v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxBytes" || e.Limit() != 10 {
+		t.Error("unmatched error", err)
+	}
+
+	err = NewDecoder(strings.NewReader(data), WithMaxBytes(11)).Decode(&v)
+	if err != nil || len(v) != 3 {
+		t.Error("input within limit should be decoded", v, err)
+	}
+
+	v = map[string]int{}
+	err = NewDecoder(strings.NewReader(data), WithMaxPairs(2)).Decode(&v)
+	if e := (ErrLimitExceeded{}); !errors.As(err, &e) || e.Name() != "MaxPairs" || e.Limit() != 2 {
+		t.Error("unmatched error", err)
+	}
+	if len(v) != 0 {
+		t.Error("nothing should be decoded after exceeding limit", v)
+	}
+
+	err = NewDecoder(strings.NewReader(data+"&"), WithMaxPairs(3)).Decode(&v)
+	if err != nil || len(v) != 3 {
+		t.Error("input within limit should be decoded", v, err)
+	}
+}
+
+func TestDecoder_Decode_Error(t *testing.T) {
+	v := map[string]int{}
+	err := NewDecoder(errorReader{}).Decode(&v)
+	if err == nil || err.Error() != "read" {
+		t.Error("unmatched error", err)
+	}
+
+	err = NewDecoder(strings.NewReader("a=%zz")).Decode(&v)
+	if err == nil {
+		t.Error("error is expected")
+	}
+
+	err = NewDecoder(strings.NewReader("a=1")).Decode(v)
+	if _, ok := err.(ErrInvalidUnmarshalError); !ok {
+		t.Error("unmatched error", err)
+	}
+
+	err = NewDecoder(strings.NewReader("a=x")).Decode(&v)
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Key != "a" {
+		t.Error("unmatched error", err)
+	}
+}
+
+func TestDecoder_RegisterDecodeFunc(t *testing.T) {
+	d := NewDecoder(strings.NewReader("A=a&C=usd"))
+	d.RegisterDecodeFunc(reflect.String, func(value string) (reflect.Value, error) {
+		return reflect.ValueOf("x"), nil
+	})
+	d.RegisterTypeDecoder(reflect.TypeOf(testCurrency("")), func(value string) (reflect.Value, error) {
+		return reflect.ValueOf(testCurrency("c")), nil
+	})
+
+	v := struct {
+		A string
+		C testCurrency
+	}{}
+	err := d.Decode(&v)
+	if err != nil || v.A != "x" || v.C != "c" {
+		t.Error("failed to register decode func", v, err)
+	}
+}
@@ -3,6 +3,7 @@ package urlquery
 import (
 	"strings"
 	"sync"
+	"time"
 )
 
 // A tag is decoration of struct attribution
@@ -28,6 +29,9 @@ func (t *tag) getName() string {
 
 // contains tag
 func (t *tag) contains(option string) bool {
+	if t == nil {
+		return false
+	}
 	var mutex sync.Mutex
 	mutex.Lock()
 	mutex.Unlock()
@@ -38,3 +42,37 @@ func (t *tag) contains(option string) bool {
 	}
 	return false
 }
+
+// get value of option like `layout=2006-01-02`
+func (t *tag) getOption(name string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	for _, o := range t.options {
+		if strings.HasPrefix(o, name+"=") {
+			return o[len(name)+1:], true
+		}
+	}
+	return "", false
+}
+
+// get time layout, default: RFC3339
+func (t *tag) getLayout() string {
+	if layout, ok := t.getOption("layout"); ok && layout != "" {
+		return layout
+	}
+	return time.RFC3339Nano
+}
+
+// get array format specified by options, eg. `query:"ids,comma"`
+func (t *tag) getArrayFormat(def ArrayFormat) ArrayFormat {
+	if t == nil {
+		return def
+	}
+	for _, o := range t.options {
+		if f, ok := arrayFormatOptions[o]; ok {
+			return f
+		}
+	}
+	return def
+}
@@ -2,6 +2,7 @@ package urlquery
 
 import (
 	"testing"
+	"time"
 )
 
 func Test_newTag(t *testing.T) {
@@ -55,3 +56,24 @@ func Test_newTag_SpecialSpace(t *testing.T) {
 		t.Error("options's length is wrong")
 	}
 }
+
+func Test_tag_getOption(t *testing.T) {
+	tg := newTag("since,unix,layout=2006-01-02")
+
+	if v, ok := tg.getOption("layout"); !ok || v != "2006-01-02" {
+		t.Error("option layout is wrong")
+	}
+
+	if _, ok := tg.getOption("unix"); ok {
+		t.Error("option unix has no value")
+	}
+
+	if tg.getLayout() != "2006-01-02" {
+		t.Error("layout is wrong")
+	}
+
+	var nilTag *tag
+	if nilTag.contains("unix") || nilTag.getLayout() != time.RFC3339Nano {
+		t.Error("nil tag is wrong")
+	}
+}
@@ -0,0 +1,54 @@
+package urlquery
+
+// A keyNode is a node of key trie, whose children are the next segments of query keys
+// eg. a[b][0]=1&a[c]=2 -> node a has children b and c, node b has child 0
+type keyNode struct {
+	children map[string]*keyNode
+	// keys of container ending at the node
+	keys []string
+}
+
+// make a new trie node
+func newKeyNode() *keyNode {
+	return &keyNode{children: map[string]*keyNode{}}
+}
+
+// insert key by its segments, which are unpacked one by one
+func (n *keyNode) insert(key string) {
+	node, rest := n, key
+	for rest != "" {
+		pre, suf := unpackQueryKey(rest)
+		child, ok := node.children[pre]
+		if !ok {
+			child = newKeyNode()
+			node.children[pre] = child
+		}
+
+		//the remaining is not nested, eg. a[b]c is under a, but not under a[b]
+		if suf != "" && suf[0] != '[' {
+			break
+		}
+		node, rest = child, suf
+	}
+	node.keys = append(node.keys, key)
+}
+
+// find the node of prefix, nil if it does not exist
+func (n *keyNode) find(prefix string) *keyNode {
+	node, rest := n, prefix
+	for rest != "" && node != nil {
+		pre, suf := unpackQueryKey(rest)
+		node, rest = node.children[pre], suf
+	}
+	return node
+}
+
+// walk all the keys of node and its descendants
+func (n *keyNode) walk(fn func(key string)) {
+	for _, key := range n.keys {
+		fn(key)
+	}
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+}
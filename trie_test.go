@@ -0,0 +1,166 @@
+package urlquery
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testWideItem struct {
+	Id    int               `query:"id"`
+	Tags  []string          `query:"tags"`
+	Attrs map[string]string `query:"attrs"`
+	Child *testParseChild   `query:"child"`
+}
+
+type testWideData struct {
+	Items []testWideItem `query:"items"`
+}
+
+// items[0][id]=0&items[0][tags][0]=t&items[0][attrs][k]=v&items[0][child][desc]=d ...
+func getWideQuery(n int) []byte {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		prefix := "items[" + strconv.Itoa(i) + "]"
+		sb.WriteString(prefix + "[id]=" + strconv.Itoa(i) + "&")
+		sb.WriteString(prefix + "[tags][0]=t&")
+		sb.WriteString(prefix + "[attrs][k]=v&")
+		sb.WriteString(prefix + "[child][desc]=d&")
+	}
+	return []byte(encodeSquareBracket(strings.TrimSuffix(sb.String(), "&")))
+}
+
+// a[b0][b1]...[b{depth}][l{i}]=v for each leaf
+func getDeepQuery(depth, leaves int) []byte {
+	key := "a"
+	for i := 0; i < depth; i++ {
+		key += "[b" + strconv.Itoa(i) + "]"
+	}
+
+	pairs := make([]string, leaves)
+	for i := range pairs {
+		pairs[i] = key + "[l" + strconv.Itoa(i) + "]=v"
+	}
+	return []byte(encodeSquareBracket(strings.Join(pairs, "&")))
+}
+
+func Test_keyNode(t *testing.T) {
+	root := newKeyNode()
+	for _, k := range []string{"a[b][0]", "a[c]", "a", "[d]", "a[b]c", "e]f"} {
+		root.insert(k)
+	}
+
+	children := func(prefix string) []string {
+		node := root.find(prefix)
+		if node == nil {
+			return nil
+		}
+		var keys []string
+		for k := range node.children {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	if keys := children(""); !reflect.DeepEqual(keys, []string{"a", "d", "e"}) {
+		t.Error("unmatched children of root", keys)
+	}
+	if keys := children("a"); !reflect.DeepEqual(keys, []string{"b", "c"}) {
+		t.Error("unmatched children of a", keys)
+	}
+	if keys := children("a[b]"); !reflect.DeepEqual(keys, []string{"0"}) {
+		t.Error("unmatched children of a[b]", keys)
+	}
+	if root.find("a[x]") != nil || root.find("x") != nil {
+		t.Error("nil node is expected")
+	}
+
+	walk := func(prefix string) []string {
+		var keys []string
+		root.find(prefix).walk(func(key string) {
+			keys = append(keys, key)
+		})
+		sort.Strings(keys)
+		return keys
+	}
+	if keys := walk("a"); !reflect.DeepEqual(keys, []string{"a", "a[b][0]", "a[b]c", "a[c]"}) {
+		t.Error("unmatched keys under a", keys)
+	}
+	if keys := walk("a[b]"); !reflect.DeepEqual(keys, []string{"a[b][0]"}) {
+		t.Error("unmatched keys under a[b]", keys)
+	}
+	if keys := walk(""); len(keys) != 6 {
+		t.Error("unmatched keys under root", keys)
+	}
+}
+
+func TestParser_lookup_Boundary(t *testing.T) {
+	data := encodeSquareBracket("tags[0]=a&tags2[0]=b&tag=c&tags=d")
+	v := struct {
+		Tags  []string `query:"tags"`
+		Tags2 []string `query:"tags2"`
+		Tag   string   `query:"tag"`
+	}{}
+	err := Unmarshal([]byte(data), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(v.Tags, []string{"a", "d"}) || !reflect.DeepEqual(v.Tags2, []string{"b"}) || v.Tag != "c" {
+		t.Error("failed to lookup by key boundary", v)
+	}
+}
+
+func TestParser_Unmarshal_Wide(t *testing.T) {
+	v := testWideData{}
+	err := Unmarshal(getWideQuery(100), &v)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(v.Items) != 100 {
+		t.Error("unmatched length of items", len(v.Items))
+		return
+	}
+	for i, item := range v.Items {
+		if item.Id != i || !reflect.DeepEqual(item.Tags, []string{"t"}) || item.Attrs["k"] != "v" ||
+			item.Child == nil || item.Child.Description != "d" {
+			t.Error("failed to Unmarshal wide query", item)
+		}
+	}
+}
+
+//BenchmarkUnmarshal_Wide 	      20	  12821300 ns/op	 5405208 B/op	   79813 allocs/op
+//without key trie:       	       3	 397945556 ns/op	 3981146 B/op	   56304 allocs/op
+func BenchmarkUnmarshal_Wide(b *testing.B) {
+	data := getWideQuery(1000)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		v := testWideData{}
+		err := Unmarshal(data, &v)
+		if err != nil {
+			b.Error(err)
+		}
+	}
+}
+
+//BenchmarkUnmarshal_Deep 	      20	    742930 ns/op	  245924 B/op	    1726 allocs/op
+//without key trie:       	       3	   1757447 ns/op	 3428176 B/op	   15505 allocs/op
+func BenchmarkUnmarshal_Deep(b *testing.B) {
+	data := getDeepQuery(50, 100)
+	//depth is beyond default limits
+	parser := NewParser(WithLimits(Limits{}))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var v interface{}
+		err := parser.Unmarshal(data, &v)
+		if err != nil {
+			b.Error(err)
+		}
+	}
+}
@@ -40,3 +40,34 @@ func (u DefaultQueryEncoder) Escape(s string) string {
 func (u DefaultQueryEncoder) UnEscape(s string) (string, error) {
 	return url.QueryUnescape(s)
 }
+
+// A RFC3986QueryEncoder is a URL-Encoder following RFC 3986, which is used by canonical query string.
+// All the characters except unreserved ones (A-Z a-z 0-9 - . _ ~) are percent-encoded with upper case hex,
+// eg. space is encoded as %20 instead of +
+type RFC3986QueryEncoder struct{}
+
+// Escape text
+func (u RFC3986QueryEncoder) Escape(s string) string {
+	const hex = "0123456789ABCDEF"
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isUnreserved(c) {
+			buf = append(buf, c)
+			continue
+		}
+		buf = append(buf, '%', hex[c>>4], hex[c&15])
+	}
+	return string(buf)
+}
+
+// UnEscape text, + is decoded as space
+func (u RFC3986QueryEncoder) UnEscape(s string) (string, error) {
+	return url.QueryUnescape(s)
+}
+
+// check if character is unreserved in RFC 3986
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
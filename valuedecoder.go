@@ -1,8 +1,16 @@
 package urlquery
 
 import (
+	"encoding"
 	"reflect"
 	"strconv"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
 )
 
 // A valueDecode is a converter from string to go basic structure
@@ -120,6 +128,54 @@ func stringDecode(value string) (reflect.Value, error) {
 	return reflect.ValueOf(value), nil
 }
 
+// convert from string to time.Time, layout is specified by options of tag
+func timeDecode(t *tag) valueDecode {
+	return func(value string) (reflect.Value, error) {
+		if t.contains("unix") || t.contains("unixmilli") {
+			v, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return reflect.Value{}, ErrTranslated{err: err}
+			}
+			if t.contains("unix") {
+				return reflect.ValueOf(time.Unix(v, 0)), nil
+			}
+			return reflect.ValueOf(time.Unix(0, v*int64(time.Millisecond))), nil
+		}
+
+		tm, err := time.Parse(t.getLayout(), value)
+		if err != nil {
+			return reflect.Value{}, ErrTranslated{err: err}
+		}
+		return reflect.ValueOf(tm), nil
+	}
+}
+
+// convert from string to time.Duration, eg. 1h30m0s
+// plain integer is also accepted as nanoseconds
+func durationDecode(value string) (reflect.Value, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		v, err1 := strconv.ParseInt(value, 10, 64)
+		if err1 != nil {
+			return reflect.Value{}, ErrTranslated{err: err}
+		}
+		d = time.Duration(v)
+	}
+	return reflect.ValueOf(d), nil
+}
+
+// get decode func for specified type which can not be distinguished by reflect kind
+func getTypeDecodeFunc(typ reflect.Type, t *tag) valueDecode {
+	switch typ {
+	case timeType:
+		return timeDecode(t)
+	case durationType:
+		return durationDecode
+	default:
+		return nil
+	}
+}
+
 // get decode func for specified reflect kind
 func getDecodeFunc(kind reflect.Kind) valueDecode {
 	switch kind {
@@ -157,3 +213,18 @@ func getDecodeFunc(kind reflect.Kind) valueDecode {
 		return nil
 	}
 }
+
+// check if type can be decoded via encoding.TextUnmarshaler of its pointer
+func isTextUnmarshaler(typ reflect.Type) bool {
+	return isPtrImplementation(typ, textUnmarshalerType)
+}
+
+// convert from string to specified type via encoding.TextUnmarshaler
+func textDecode(typ reflect.Type, value string) (reflect.Value, error) {
+	v := reflect.New(typ)
+	err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	if err != nil {
+		return reflect.Value{}, ErrTranslated{err: err}
+	}
+	return v.Elem(), nil
+}
@@ -1,10 +1,14 @@
 package urlquery
 
 import (
+	"encoding"
 	"reflect"
 	"strconv"
+	"time"
 )
 
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
 // A valueEncode is a converter from go basic structure to string
 type valueEncode func(value reflect.Value) string
 
@@ -36,6 +40,37 @@ func stringEncode(value reflect.Value) string {
 	return value.String()
 }
 
+// converter from time.Time to string, layout is specified by options of tag
+func timeEncode(t *tag) valueEncode {
+	return func(value reflect.Value) string {
+		tm := value.Interface().(time.Time)
+		switch {
+		case t.contains("unix"):
+			return strconv.FormatInt(tm.Unix(), 10)
+		case t.contains("unixmilli"):
+			return strconv.FormatInt(tm.UnixNano()/int64(time.Millisecond), 10)
+		}
+		return tm.Format(t.getLayout())
+	}
+}
+
+// converter from time.Duration to string, eg. 1h30m0s
+func durationEncode(value reflect.Value) string {
+	return time.Duration(value.Int()).String()
+}
+
+// get encode func for specified type which can not be distinguished by reflect kind
+func getTypeEncodeFunc(typ reflect.Type, t *tag) valueEncode {
+	switch typ {
+	case timeType:
+		return timeEncode(t)
+	case durationType:
+		return durationEncode
+	default:
+		return nil
+	}
+}
+
 // get encode func for specified reflect kind
 func getEncodeFunc(kind reflect.Kind) valueEncode {
 	switch kind {
@@ -53,3 +88,12 @@ func getEncodeFunc(kind reflect.Kind) valueEncode {
 		return nil
 	}
 }
+
+// get encoding.TextMarshaler implemented by value or pointer of value
+func getTextMarshaler(rv reflect.Value) (encoding.TextMarshaler, bool) {
+	m, ok := getImplementation(rv, textMarshalerType)
+	if !ok {
+		return nil, false
+	}
+	return m.(encoding.TextMarshaler), true
+}